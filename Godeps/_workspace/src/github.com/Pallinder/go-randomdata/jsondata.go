@@ -1,1986 +0,0 @@
-package randomdata
-
-var data = []byte(`{
-    "adjectives": [
-        "black",
-        "white",
-        "gray",
-        "brown",
-        "red",
-        "pink",
-        "crimson",
-        "carnelian",
-        "orange",
-        "yellow",
-        "ivory",
-        "cream",
-        "green",
-        "viridian",
-        "aquamarine",
-        "cyan",
-        "blue",
-        "cerulean",
-        "azure",
-        "indigo",
-        "navy",
-        "violet",
-        "purple",
-        "lavender",
-        "magenta",
-        "rainbow",
-        "iridescent",
-        "spectrum",
-        "prism",
-        "bold",
-        "vivid",
-        "pale",
-        "clear",
-        "glass",
-        "translucent",
-        "misty",
-        "dark",
-        "light",
-        "gold",
-        "silver",
-        "copper",
-        "bronze",
-        "steel",
-        "iron",
-        "brass",
-        "mercury",
-        "zinc",
-        "chrome",
-        "platinum",
-        "titanium",
-        "nickel",
-        "lead",
-        "pewter",
-        "rust",
-        "metal",
-        "stone",
-        "quartz",
-        "granite",
-        "marble",
-        "alabaster",
-        "agate",
-        "jasper",
-        "pebble",
-        "pyrite",
-        "crystal",
-        "geode",
-        "obsidian",
-        "mica",
-        "flint",
-        "sand",
-        "gravel",
-        "boulder",
-        "basalt",
-        "ruby",
-        "beryl",
-        "scarlet",
-        "citrine",
-        "sulpher",
-        "topaz",
-        "amber",
-        "emerald",
-        "malachite",
-        "jade",
-        "abalone",
-        "lapis",
-        "sapphire",
-        "diamond",
-        "peridot",
-        "gem",
-        "jewel",
-        "bevel",
-        "coral",
-        "jet",
-        "ebony",
-        "wood",
-        "tree",
-        "cherry",
-        "maple",
-        "cedar",
-        "branch",
-        "bramble",
-        "rowan",
-        "ash",
-        "fir",
-        "pine",
-        "cactus",
-        "alder",
-        "grove",
-        "forest",
-        "jungle",
-        "palm",
-        "bush",
-        "mulberry",
-        "juniper",
-        "vine",
-        "ivy",
-        "rose",
-        "lily",
-        "tulip",
-        "daffodil",
-        "honeysuckle",
-        "fuschia",
-        "hazel",
-        "walnut",
-        "almond",
-        "lime",
-        "lemon",
-        "apple",
-        "blossom",
-        "bloom",
-        "crocus",
-        "rose",
-        "buttercup",
-        "dandelion",
-        "iris",
-        "carnation",
-        "fern",
-        "root",
-        "branch",
-        "leaf",
-        "seed",
-        "flower",
-        "petal",
-        "pollen",
-        "orchid",
-        "mangrove",
-        "cypress",
-        "sequoia",
-        "sage",
-        "heather",
-        "snapdragon",
-        "daisy",
-        "mountain",
-        "hill",
-        "alpine",
-        "chestnut",
-        "valley",
-        "glacier",
-        "forest",
-        "grove",
-        "glen",
-        "tree",
-        "thorn",
-        "stump",
-        "desert",
-        "canyon",
-        "dune",
-        "oasis",
-        "mirage",
-        "well",
-        "spring",
-        "meadow",
-        "field",
-        "prairie",
-        "grass",
-        "tundra",
-        "island",
-        "shore",
-        "sand",
-        "shell",
-        "surf",
-        "wave",
-        "foam",
-        "tide",
-        "lake",
-        "river",
-        "brook",
-        "stream",
-        "pool",
-        "pond",
-        "sun",
-        "sprinkle",
-        "shade",
-        "shadow",
-        "rain",
-        "cloud",
-        "storm",
-        "hail",
-        "snow",
-        "sleet",
-        "thunder",
-        "lightning",
-        "wind",
-        "hurricane",
-        "typhoon",
-        "dawn",
-        "sunrise",
-        "morning",
-        "noon",
-        "twilight",
-        "evening",
-        "sunset",
-        "midnight",
-        "night",
-        "sky",
-        "star",
-        "stellar",
-        "comet",
-        "nebula",
-        "quasar",
-        "solar",
-        "lunar",
-        "planet",
-        "meteor",
-        "sprout",
-        "pear",
-        "plum",
-        "kiwi",
-        "berry",
-        "apricot",
-        "peach",
-        "mango",
-        "pineapple",
-        "coconut",
-        "olive",
-        "ginger",
-        "root",
-        "plain",
-        "fancy",
-        "stripe",
-        "spot",
-        "speckle",
-        "spangle",
-        "ring",
-        "band",
-        "blaze",
-        "paint",
-        "pinto",
-        "shade",
-        "tabby",
-        "brindle",
-        "patch",
-        "calico",
-        "checker",
-        "dot",
-        "pattern",
-        "glitter",
-        "glimmer",
-        "shimmer",
-        "dull",
-        "dust",
-        "dirt",
-        "glaze",
-        "scratch",
-        "quick",
-        "swift",
-        "fast",
-        "slow",
-        "clever",
-        "fire",
-        "flicker",
-        "flash",
-        "spark",
-        "ember",
-        "coal",
-        "flame",
-        "chocolate",
-        "vanilla",
-        "sugar",
-        "spice",
-        "cake",
-        "pie",
-        "cookie",
-        "candy",
-        "caramel",
-        "spiral",
-        "round",
-        "jelly",
-        "square",
-        "narrow",
-        "long",
-        "short",
-        "small",
-        "tiny",
-        "big",
-        "giant",
-        "great",
-        "atom",
-        "peppermint",
-        "mint",
-        "butter",
-        "fringe",
-        "rag",
-        "quilt",
-        "truth",
-        "lie",
-        "holy",
-        "curse",
-        "noble",
-        "sly",
-        "brave",
-        "shy",
-        "lava",
-        "foul",
-        "leather",
-        "fantasy",
-        "keen",
-        "luminous",
-        "feather",
-        "sticky",
-        "gossamer",
-        "cotton",
-        "rattle",
-        "silk",
-        "satin",
-        "cord",
-        "denim",
-        "flannel",
-        "plaid",
-        "wool",
-        "linen",
-        "silent",
-        "flax",
-        "weak",
-        "valiant",
-        "fierce",
-        "gentle",
-        "rhinestone",
-        "splash",
-        "north",
-        "south",
-        "east",
-        "west",
-        "summer",
-        "winter",
-        "autumn",
-        "spring",
-        "season",
-        "equinox",
-        "solstice",
-        "paper",
-        "motley",
-        "torch",
-        "ballistic",
-        "rampant",
-        "shag",
-        "freckle",
-        "wild",
-        "free",
-        "chain",
-        "sheer",
-        "crazy",
-        "mad",
-        "candle",
-        "ribbon",
-        "lace",
-        "notch",
-        "wax",
-        "shine",
-        "shallow",
-        "deep",
-        "bubble",
-        "harvest",
-        "fluff",
-        "venom",
-        "boom",
-        "slash",
-        "rune",
-        "cold",
-        "quill",
-        "love",
-        "hate",
-        "garnet",
-        "zircon",
-        "power",
-        "bone",
-        "void",
-        "horn",
-        "glory",
-        "cyber",
-        "nova",
-        "hot",
-        "helix",
-        "cosmic",
-        "quark",
-        "quiver",
-        "holly",
-        "clover",
-        "polar",
-        "regal",
-        "ripple",
-        "ebony",
-        "wheat",
-        "phantom",
-        "dew",
-        "chisel",
-        "crack",
-        "chatter",
-        "laser",
-        "foil",
-        "tin",
-        "clever",
-        "treasure",
-        "maze",
-        "twisty",
-        "curly",
-        "fortune",
-        "fate",
-        "destiny",
-        "cute",
-        "slime",
-        "ink",
-        "disco",
-        "plume",
-        "time",
-        "psychadelic",
-        "relic",
-        "fossil",
-        "water",
-        "savage",
-        "ancient",
-        "rapid",
-        "road",
-        "trail",
-        "stitch",
-        "button",
-        "bow",
-        "nimble",
-        "zest",
-        "sour",
-        "bitter",
-        "phase",
-        "fan",
-        "frill",
-        "plump",
-        "pickle",
-        "mud",
-        "puddle",
-        "pond",
-        "river",
-        "spring",
-        "stream",
-        "battle",
-        "arrow",
-        "plume",
-        "roan",
-        "pitch",
-        "tar",
-        "cat",
-        "dog",
-        "horse",
-        "lizard",
-        "bird",
-        "fish",
-        "saber",
-        "scythe",
-        "sharp",
-        "soft",
-        "razor",
-        "neon",
-        "dandy",
-        "weed",
-        "swamp",
-        "marsh",
-        "bog",
-        "peat",
-        "moor",
-        "muck",
-        "mire",
-        "grave",
-        "fair",
-        "just",
-        "brick",
-        "puzzle",
-        "skitter",
-        "prong",
-        "fork",
-        "dent",
-        "dour",
-        "warp",
-        "luck",
-        "coffee",
-        "split",
-        "chip",
-        "hollow",
-        "heavy",
-        "legend",
-        "hickory",
-        "mesquite",
-        "nettle",
-        "rogue",
-        "charm",
-        "prickle",
-        "bead",
-        "sponge",
-        "whip",
-        "bald",
-        "frost",
-        "fog",
-        "oil",
-        "veil",
-        "cliff",
-        "volcano",
-        "rift",
-        "maze",
-        "proud",
-        "dew",
-        "mirror",
-        "shard",
-        "salt",
-        "pepper",
-        "honey",
-        "thread",
-        "bristle",
-        "ripple",
-        "glow",
-        "zenith"
-    ],
-    "nouns": [
-        "head",
-        "crest",
-        "crown",
-        "tooth",
-        "fang",
-        "horn",
-        "frill",
-        "skull",
-        "bone",
-        "tongue",
-        "throat",
-        "voice",
-        "nose",
-        "snout",
-        "chin",
-        "eye",
-        "sight",
-        "seer",
-        "speaker",
-        "singer",
-        "song",
-        "chanter",
-        "howler",
-        "chatter",
-        "shrieker",
-        "shriek",
-        "jaw",
-        "bite",
-        "biter",
-        "neck",
-        "shoulder",
-        "fin",
-        "wing",
-        "arm",
-        "lifter",
-        "grasp",
-        "grabber",
-        "hand",
-        "paw",
-        "foot",
-        "finger",
-        "toe",
-        "thumb",
-        "talon",
-        "palm",
-        "touch",
-        "racer",
-        "runner",
-        "hoof",
-        "fly",
-        "flier",
-        "swoop",
-        "roar",
-        "hiss",
-        "hisser",
-        "snarl",
-        "dive",
-        "diver",
-        "rib",
-        "chest",
-        "back",
-        "ridge",
-        "leg",
-        "legs",
-        "tail",
-        "beak",
-        "walker",
-        "lasher",
-        "swisher",
-        "carver",
-        "kicker",
-        "roarer",
-        "crusher",
-        "spike",
-        "shaker",
-        "charger",
-        "hunter",
-        "weaver",
-        "crafter",
-        "binder",
-        "scribe",
-        "muse",
-        "snap",
-        "snapper",
-        "slayer",
-        "stalker",
-        "track",
-        "tracker",
-        "scar",
-        "scarer",
-        "fright",
-        "killer",
-        "death",
-        "doom",
-        "healer",
-        "saver",
-        "friend",
-        "foe",
-        "guardian",
-        "thunder",
-        "lightning",
-        "cloud",
-        "storm",
-        "forger",
-        "scale",
-        "hair",
-        "braid",
-        "nape",
-        "belly",
-        "thief",
-        "stealer",
-        "reaper",
-        "giver",
-        "taker",
-        "dancer",
-        "player",
-        "gambler",
-        "twister",
-        "turner",
-        "painter",
-        "dart",
-        "drifter",
-        "sting",
-        "stinger",
-        "venom",
-        "spur",
-        "ripper",
-        "swallow",
-        "devourer",
-        "knight",
-        "lady",
-        "lord",
-        "queen",
-        "king",
-        "master",
-        "mistress",
-        "prince",
-        "princess",
-        "duke",
-        "dutchess",
-        "samurai",
-        "ninja",
-        "knave",
-        "slave",
-        "servant",
-        "sage",
-        "wizard",
-        "witch",
-        "warlock",
-        "warrior",
-        "jester",
-        "paladin",
-        "bard",
-        "trader",
-        "sword",
-        "shield",
-        "knife",
-        "dagger",
-        "arrow",
-        "bow",
-        "fighter",
-        "bane",
-        "follower",
-        "leader",
-        "scourge",
-        "watcher",
-        "cat",
-        "panther",
-        "tiger",
-        "cougar",
-        "puma",
-        "jaguar",
-        "ocelot",
-        "lynx",
-        "lion",
-        "leopard",
-        "ferret",
-        "weasel",
-        "wolverine",
-        "bear",
-        "raccoon",
-        "dog",
-        "wolf",
-        "kitten",
-        "puppy",
-        "cub",
-        "fox",
-        "hound",
-        "terrier",
-        "coyote",
-        "hyena",
-        "jackal",
-        "pig",
-        "horse",
-        "donkey",
-        "stallion",
-        "mare",
-        "zebra",
-        "antelope",
-        "gazelle",
-        "deer",
-        "buffalo",
-        "bison",
-        "boar",
-        "elk",
-        "whale",
-        "dolphin",
-        "shark",
-        "fish",
-        "minnow",
-        "salmon",
-        "ray",
-        "fisher",
-        "otter",
-        "gull",
-        "duck",
-        "goose",
-        "crow",
-        "raven",
-        "bird",
-        "eagle",
-        "raptor",
-        "hawk",
-        "falcon",
-        "moose",
-        "heron",
-        "owl",
-        "stork",
-        "crane",
-        "sparrow",
-        "robin",
-        "parrot",
-        "cockatoo",
-        "carp",
-        "lizard",
-        "gecko",
-        "iguana",
-        "snake",
-        "python",
-        "viper",
-        "boa",
-        "condor",
-        "vulture",
-        "spider",
-        "fly",
-        "scorpion",
-        "heron",
-        "oriole",
-        "toucan",
-        "bee",
-        "wasp",
-        "hornet",
-        "rabbit",
-        "bunny",
-        "hare",
-        "brow",
-        "mustang",
-        "ox",
-        "piper",
-        "soarer",
-        "flasher",
-        "moth",
-        "mask",
-        "hide",
-        "hero",
-        "antler",
-        "chill",
-        "chiller",
-        "gem",
-        "ogre",
-        "myth",
-        "elf",
-        "fairy",
-        "pixie",
-        "dragon",
-        "griffin",
-        "unicorn",
-        "pegasus",
-        "sprite",
-        "fancier",
-        "chopper",
-        "slicer",
-        "skinner",
-        "butterfly",
-        "legend",
-        "wanderer",
-        "rover",
-        "raver",
-        "loon",
-        "lancer",
-        "glass",
-        "glazer",
-        "flame",
-        "crystal",
-        "lantern",
-        "lighter",
-        "cloak",
-        "bell",
-        "ringer",
-        "keeper",
-        "centaur",
-        "bolt",
-        "catcher",
-        "whimsey",
-        "quester",
-        "rat",
-        "mouse",
-        "serpent",
-        "wyrm",
-        "gargoyle",
-        "thorn",
-        "whip",
-        "rider",
-        "spirit",
-        "sentry",
-        "bat",
-        "beetle",
-        "burn",
-        "cowl",
-        "stone",
-        "gem",
-        "collar",
-        "mark",
-        "grin",
-        "scowl",
-        "spear",
-        "razor",
-        "edge",
-        "seeker",
-        "jay",
-        "ape",
-        "monkey",
-        "gorilla",
-        "koala",
-        "kangaroo",
-        "yak",
-        "sloth",
-        "ant",
-        "roach",
-        "weed",
-        "seed",
-        "eater",
-        "razor",
-        "shirt",
-        "face",
-        "goat",
-        "mind",
-        "shift",
-        "rider",
-        "face",
-        "mole",
-        "vole",
-        "pirate",
-        "llama",
-        "stag",
-        "bug",
-        "cap",
-        "boot",
-        "drop",
-        "hugger",
-        "sargent",
-        "snagglefoot",
-        "carpet",
-        "curtain"
-    ],
-    "firstNamesMale": [
-        "Jacob",
-        "Mason",
-        "Ethan",
-        "Noah",
-        "William",
-        "Liam",
-        "Jayden",
-        "Michael",
-        "Alexander",
-        "Aiden",
-        "Daniel",
-        "Matthew",
-        "Elijah",
-        "James",
-        "Anthony",
-        "Benjamin",
-        "Joshua",
-        "Andrew",
-        "David",
-        "Joseph"
-    ],
-    "firstNamesFemale": [
-        "Sophia",
-        "Emma",
-        "Isabella",
-        "Olivia",
-        "Ava",
-        "Emily",
-        "Abigail",
-        "Mia",
-        "Madison",
-        "Elizabeth",
-        "Chloe",
-        "Ella",
-        "Avery",
-        "Addison",
-        "Aubrey",
-        "Lily",
-        "Natalie",
-        "Sofia",
-        "Charlotte",
-        "Zoey"
-    ],
-    "lastNames": [
-        "Smith",
-        "Johnson",
-        "Williams",
-        "Jones",
-        "Brown",
-        "Davis",
-        "Miller",
-        "Wilson",
-        "Moore",
-        "Taylor",
-        "Anderson",
-        "Thomas",
-        "Jackson",
-        "White",
-        "Harris",
-        "Martin",
-        "Thompson",
-        "Garcia",
-        "Martinez",
-        "Robinson"
-    ],
-    "domains": [
-        "test.com",
-        "example.com"
-    ],
-    "people": [
-        "Adams",
-        "Franklin",
-        "Jackson",
-        "Jefferson",
-        "Lincoln",
-        "Madison",
-        "Washington",
-        "Wilson"
-    ],
-    "streetTypes": [
-        "St",
-        "Ave",
-        "Rd",
-        "Blvd",
-        "Trl",
-        "Ter",
-        "Rdg",
-        "Pl",
-        "Pkwy",
-        "Ct",
-        "Circle"
-    ],
-    "paragraphs": [
-        "The Nellie, a cruising yawl, swung to her anchor without a flutter of the sails, and was at rest.",
-        "The sun set; the dusk fell on the stream, and lights began to appear along the shore. The Chapman light–house, a three–legged thing erect on a mud–flat, shone strongly.",
-        "He spoke of the happiness that was now certainly theirs, of the folly of not breaking sooner out of that magnificent prison of latter-day life, of the old romantic days that had passed from the world for ever.",
-        "One dog rolled before him, well-nigh slashed in half; but a second had him by the thigh, a third gripped his collar be- hind, and a fourth had the blade of the sword between its teeth, tasting its own blood.",
-        "She stared at him in astonishment, and as she read something of the significant hieroglyphic of his battered face, her lips whitened.",
-        "He completely abandoned the child of his marriage with Adelaida Ivanovna, not from malice, nor because of his matrimoni- al grievances, but simply because he forgot him.",
-        "It was at this time that the meeting, or, rather gathering of the mem- bers of this inharmonious family took place in the cell of the elder who had such an extraordinary influence on Alyosha.",
-        "The secular cooling that must someday overtake our planet has already gone far indeed with our neighbour.",
-        "Near it in the field, I remember, were three faint points of light, three telescopic stars infinitely remote, and all around it was the unfathomable darkness of empty space."
-    ],
-    "countries": [
-        "Afghanistan",
-        "Albania",
-        "Algeria",
-        "American Samoa",
-        "Andorra",
-        "Angola",
-        "Antigua and Barbuda",
-        "Argentina",
-        "Armenia",
-        "Aruba",
-        "Australia",
-        "Austria",
-        "Azerbaijan",
-        "Bahamas, The",
-        "Bahrain",
-        "Bangladesh",
-        "Barbados",
-        "Belarus",
-        "Belgium",
-        "Belize",
-        "Benin",
-        "Bermuda",
-        "Bhutan",
-        "Bolivia",
-        "Bosnia and Herzegovina",
-        "Botswana",
-        "Brazil",
-        "Brunei Darussalam",
-        "Bulgaria",
-        "Burkina Faso",
-        "Burundi",
-        "Cambodia",
-        "Cameroon",
-        "Canada",
-        "Cape Verde",
-        "Cayman Islands",
-        "Central African Republic",
-        "Chad",
-        "Channel Islands",
-        "Chile",
-        "China",
-        "Colombia",
-        "Comoros",
-        "Congo, Dem. Rep.",
-        "Congo, Rep.",
-        "Costa Rica",
-        "Côte dIvoire",
-        "Croatia",
-        "Cuba",
-        "Cyprus",
-        "Czech Republic",
-        "Denmark",
-        "Djibouti",
-        "Dominica",
-        "Dominican Republic",
-        "Ecuador",
-        "Egypt, Arab Rep.",
-        "El Salvador",
-        "Equatorial Guinea",
-        "Eritrea",
-        "Estonia",
-        "Ethiopia",
-        "Faeroe Islands",
-        "Fiji",
-        "Finland",
-        "France",
-        "French Polynesia",
-        "Gabon",
-        "Gambia, The",
-        "Georgia",
-        "Germany",
-        "Ghana",
-        "Greece",
-        "Greenland",
-        "Grenada",
-        "Guam",
-        "Guatemala",
-        "Guinea",
-        "Guinea-Bissau",
-        "Guyana",
-        "Haiti",
-        "Honduras",
-        "Hong Kong, China",
-        "Hungary",
-        "Iceland",
-        "India",
-        "Indonesia",
-        "Iran, Islamic Rep.",
-        "Iraq",
-        "Ireland",
-        "Isle of Man",
-        "Israel",
-        "Italy",
-        "Jamaica",
-        "Japan",
-        "Jordan",
-        "Kazakhstan",
-        "Kenya",
-        "Kiribati",
-        "Korea, Dem. Rep.",
-        "Korea, Rep.",
-        "Kuwait",
-        "Kyrgyz Republic",
-        "Lao PDR",
-        "Latvia",
-        "Lebanon",
-        "Lesotho",
-        "Liberia",
-        "Libya",
-        "Liechtenstein",
-        "Lithuania",
-        "Luxembourg",
-        "Macao, China",
-        "Macedonia, FYR",
-        "Madagascar",
-        "Malawi",
-        "Malaysia",
-        "Maldives",
-        "Mali",
-        "Malta",
-        "Marshall Islands",
-        "Mauritania",
-        "Mauritius",
-        "Mayotte",
-        "Mexico",
-        "Micronesia, Fed. Sts.",
-        "Moldova",
-        "Monaco",
-        "Mongolia",
-        "Montenegro",
-        "Morocco",
-        "Mozambique",
-        "Myanmar",
-        "Namibia",
-        "Nepal",
-        "Netherlands",
-        "Netherlands Antilles",
-        "New Caledonia",
-        "New Zealand",
-        "Nicaragua",
-        "Niger",
-        "Nigeria",
-        "Northern Mariana Islands",
-        "Norway",
-        "Oman",
-        "Pakistan",
-        "Palau",
-        "Panama",
-        "Papua New Guinea",
-        "Paraguay",
-        "Peru",
-        "Philippines",
-        "Poland",
-        "Portugal",
-        "Puerto Rico",
-        "Qatar",
-        "Romania",
-        "Russian Federation",
-        "Rwanda",
-        "Samoa",
-        "San Marino",
-        "São Tomé and Principe",
-        "Saudi Arabia",
-        "Senegal",
-        "Serbia",
-        "Seychelles",
-        "Sierra Leone",
-        "Singapore",
-        "Slovak Republic",
-        "Slovenia",
-        "Solomon Islands",
-        "Somalia",
-        "South Africa",
-        "Spain",
-        "Sri Lanka",
-        "St. Kitts and Nevis",
-        "St. Lucia",
-        "St. Vincent and the Grenadines",
-        "Sudan",
-        "Suriname",
-        "Swaziland",
-        "Sweden",
-        "Switzerland",
-        "Syrian Arab Republic",
-        "Tajikistan",
-        "Tanzania",
-        "Thailand",
-        "Timor-Leste",
-        "Togo",
-        "Tonga",
-        "Trinidad and Tobago",
-        "Tunisia",
-        "Turkey",
-        "Turkmenistan",
-        "Uganda",
-        "Ukraine",
-        "United Arab Emirates",
-        "United Kingdom",
-        "United States",
-        "Uruguay",
-        "Uzbekistan",
-        "Vanuatu",
-        "Venezuela, RB",
-        "Vietnam",
-        "Virgin Islands (U.S.)",
-        "West Bank and Gaza",
-        "Yemen, Rep.",
-        "Zambia",
-        "Zimbabwe"
-    ],
-    "countriesThreeChars": [
-        "AFG",
-        "ALB",
-        "DZA",
-        "ASM",
-        "ADO",
-        "AGO",
-        "ATG",
-        "ARG",
-        "ARM",
-        "ABW",
-        "AUS",
-        "AUT",
-        "AZE",
-        "BHS",
-        "BHR",
-        "BGD",
-        "BRB",
-        "BLR",
-        "BEL",
-        "BLZ",
-        "BEN",
-        "BMU",
-        "BTN",
-        "BOL",
-        "BIH",
-        "BWA",
-        "BRA",
-        "BRN",
-        "BGR",
-        "BFA",
-        "BDI",
-        "KHM",
-        "CMR",
-        "CAN",
-        "CPV",
-        "CYM",
-        "CAF",
-        "TCD",
-        "CHI",
-        "CHL",
-        "CHN",
-        "COL",
-        "COM",
-        "ZAR",
-        "COG",
-        "CRI",
-        "CIV",
-        "HRV",
-        "CUB",
-        "CUW",
-        "CYP",
-        "CZE",
-        "DNK",
-        "DJI",
-        "DMA",
-        "DOM",
-        "ECU",
-        "EGY",
-        "SLV",
-        "GNQ",
-        "ERI",
-        "EST",
-        "ETH",
-        "FRO",
-        "FJI",
-        "FIN",
-        "FRA",
-        "PYF",
-        "GAB",
-        "GMB",
-        "GEO",
-        "DEU",
-        "GHA",
-        "GRC",
-        "GRL",
-        "GRD",
-        "GUM",
-        "GTM",
-        "GIN",
-        "GNB",
-        "GUY",
-        "HTI",
-        "HND",
-        "HKG",
-        "HUN",
-        "ISL",
-        "IND",
-        "IDN",
-        "IRN",
-        "IRQ",
-        "IRL",
-        "IMY",
-        "ISR",
-        "ITA",
-        "JAM",
-        "JPN",
-        "JOR",
-        "KAZ",
-        "KEN",
-        "KIR",
-        "PRK",
-        "KOR",
-        "KSV",
-        "KWT",
-        "KGZ",
-        "LAO",
-        "LVA",
-        "LBN",
-        "LSO",
-        "LBR",
-        "LBY",
-        "LIE",
-        "LTU",
-        "LUX",
-        "MAC",
-        "MKD",
-        "MDG",
-        "MWI",
-        "MYS",
-        "MDV",
-        "MLI",
-        "MLT",
-        "MHL",
-        "MRT",
-        "MUS",
-        "MEX",
-        "FSM",
-        "MDA",
-        "MCO",
-        "MNG",
-        "MNE",
-        "MAR",
-        "MOZ",
-        "MMR",
-        "NAM",
-        "NPL",
-        "NLD",
-        "NCL",
-        "NZL",
-        "NIC",
-        "NER",
-        "NGA",
-        "MNP",
-        "NOR",
-        "OMN",
-        "PAK",
-        "PLW",
-        "PAN",
-        "PNG",
-        "PRY",
-        "PER",
-        "PHL",
-        "POL",
-        "PRT",
-        "PRI",
-        "QAT",
-        "ROM",
-        "RUS",
-        "RWA",
-        "WSM",
-        "SMR",
-        "STP",
-        "SAU",
-        "SEN",
-        "SRB",
-        "SYC",
-        "SLE",
-        "SGP",
-        "SXM",
-        "SVK",
-        "SVN",
-        "SLB",
-        "SOM",
-        "ZAF",
-        "SSD",
-        "ESP",
-        "LKA",
-        "KNA",
-        "LCA",
-        "MAF",
-        "VCT",
-        "SDN",
-        "SUR",
-        "SWZ",
-        "SWE",
-        "CHE",
-        "SYR",
-        "TJK",
-        "TZA",
-        "THA",
-        "TMP",
-        "TGO",
-        "TON",
-        "TTO",
-        "TUN",
-        "TUR",
-        "TKM",
-        "TCA",
-        "TUV",
-        "UGA",
-        "UKR",
-        "ARE",
-        "GBR",
-        "USA",
-        "URY",
-        "UZB",
-        "VUT",
-        "VEN",
-        "VNM",
-        "VIR",
-        "WBG",
-        "YEM",
-        "ZMB",
-        "ZWE"
-    ],
-    "countriesTwoChars": [
-        "AF",
-        "AX",
-        "AL",
-        "DZ",
-        "AS",
-        "AD",
-        "AO",
-        "AI",
-        "AQ",
-        "AG",
-        "AR",
-        "AM",
-        "AW",
-        "AU",
-        "AT",
-        "AZ",
-        "BS",
-        "BH",
-        "BD",
-        "BB",
-        "BY",
-        "BE",
-        "BZ",
-        "BJ",
-        "BM",
-        "BT",
-        "BO",
-        "BQ",
-        "BA",
-        "BW",
-        "BV",
-        "BR",
-        "IO",
-        "BN",
-        "BG",
-        "BF",
-        "BI",
-        "KH",
-        "CM",
-        "CA",
-        "CV",
-        "KY",
-        "CF",
-        "TD",
-        "CL",
-        "CN",
-        "CX",
-        "CC",
-        "CO",
-        "KM",
-        "CG",
-        "CD",
-        "CK",
-        "CR",
-        "CI",
-        "HR",
-        "CU",
-        "CW",
-        "CY",
-        "CZ",
-        "DK",
-        "DJ",
-        "DM",
-        "DO",
-        "EC",
-        "EG",
-        "SV",
-        "GQ",
-        "ER",
-        "EE",
-        "ET",
-        "FK",
-        "FO",
-        "FJ",
-        "FI",
-        "FR",
-        "GF",
-        "PF",
-        "TF",
-        "GA",
-        "GM",
-        "GE",
-        "DE",
-        "GH",
-        "GI",
-        "GR",
-        "GL",
-        "GD",
-        "GP",
-        "GU",
-        "GT",
-        "GG",
-        "GN",
-        "GW",
-        "GY",
-        "HT",
-        "HM",
-        "VA",
-        "HN",
-        "HK",
-        "HU",
-        "IS",
-        "IN",
-        "ID",
-        "IR",
-        "IQ",
-        "IE",
-        "IM",
-        "IL",
-        "IT",
-        "JM",
-        "JP",
-        "JE",
-        "JO",
-        "KZ",
-        "KE",
-        "KI",
-        "KP",
-        "KR",
-        "KW",
-        "KG",
-        "LA",
-        "LV",
-        "LB",
-        "LS",
-        "LR",
-        "LY",
-        "LI",
-        "LT",
-        "LU",
-        "MO",
-        "MK",
-        "MG",
-        "MW",
-        "MY",
-        "MV",
-        "ML",
-        "MT",
-        "MH",
-        "MQ",
-        "MR",
-        "MU",
-        "YT",
-        "MX",
-        "FM",
-        "MD",
-        "MC",
-        "MN",
-        "ME",
-        "MS",
-        "MA",
-        "MZ",
-        "MM",
-        "NA",
-        "NR",
-        "NP",
-        "NL",
-        "NC",
-        "NZ",
-        "NI",
-        "NE",
-        "NG",
-        "NU",
-        "NF",
-        "MP",
-        "NO",
-        "OM",
-        "PK",
-        "PW",
-        "PS",
-        "PA",
-        "PG",
-        "PY",
-        "PE",
-        "PH",
-        "PN",
-        "PL",
-        "PT",
-        "PR",
-        "QA",
-        "RE",
-        "RO",
-        "RU",
-        "RW",
-        "BL",
-        "SH",
-        "KN",
-        "LC",
-        "MF",
-        "PM",
-        "VC",
-        "WS",
-        "SM",
-        "ST",
-        "SA",
-        "SN",
-        "RS",
-        "SC",
-        "SL",
-        "SG",
-        "SX",
-        "SK",
-        "SI",
-        "SB",
-        "SO",
-        "ZA",
-        "GS",
-        "SS",
-        "ES",
-        "LK",
-        "SD",
-        "SR",
-        "SJ",
-        "SZ",
-        "SE",
-        "CH",
-        "SY",
-        "TW",
-        "TJ",
-        "TZ",
-        "TH",
-        "TL",
-        "TG",
-        "TK",
-        "TO",
-        "TT",
-        "TN",
-        "TR",
-        "TM",
-        "TC",
-        "TV",
-        "UG",
-        "UA",
-        "AE",
-        "GB",
-        "US",
-        "UM",
-        "UY",
-        "UZ",
-        "VU",
-        "VE",
-        "VN",
-        "VG",
-        "VI",
-        "WF",
-        "EH",
-        "YE",
-        "ZM",
-        "ZW"
-    ],
-    "currencies": [
-        "AED",
-        "AFN",
-        "ALL",
-        "AMD",
-        "ANG",
-        "AOA",
-        "ARS",
-        "AUD",
-        "AWG",
-        "AZN",
-        "BAM",
-        "BBD",
-        "BDT",
-        "BGN",
-        "BHD",
-        "BIF",
-        "BMD",
-        "BND",
-        "BOB",
-        "BOV",
-        "BRL",
-        "BSD",
-        "BTN",
-        "BWP",
-        "BYR",
-        "BZD",
-        "CAD",
-        "CDF",
-        "CHE",
-        "CHF",
-        "CHW",
-        "CLF",
-        "CLP",
-        "CNY",
-        "COP",
-        "COU",
-        "CRC",
-        "CUC",
-        "CUP",
-        "CVE",
-        "CZK",
-        "DJF",
-        "DKK",
-        "DOP",
-        "DZD",
-        "EGP",
-        "ERN",
-        "ETB",
-        "EUR",
-        "FJD",
-        "FKP",
-        "GBP",
-        "GEL",
-        "GHS",
-        "GIP",
-        "GMD",
-        "GNF",
-        "GTQ",
-        "GYD",
-        "HKD",
-        "HNL",
-        "HRK",
-        "HTG",
-        "HUF",
-        "IDR",
-        "ILS",
-        "INR",
-        "IQD",
-        "IRR",
-        "ISK",
-        "JMD",
-        "JOD",
-        "JPY",
-        "KES",
-        "KGS",
-        "KHR",
-        "KMF",
-        "KPW",
-        "KRW",
-        "KWD",
-        "KYD",
-        "KZT",
-        "LAK",
-        "LBP",
-        "LKR",
-        "LRD",
-        "LSL",
-        "LTL",
-        "LVL",
-        "LYD",
-        "MAD",
-        "MDL",
-        "MGA",
-        "MKD",
-        "MMK",
-        "MNT",
-        "MOP",
-        "MRO",
-        "MUR",
-        "MVR",
-        "MWK",
-        "MXN",
-        "MXV",
-        "MYR",
-        "MZN",
-        "NAD",
-        "NGN",
-        "NIO",
-        "NOK",
-        "NPR",
-        "NZD",
-        "OMR",
-        "PAB",
-        "PEN",
-        "PGK",
-        "PHP",
-        "PKR",
-        "PLN",
-        "PYG",
-        "QAR",
-        "RON",
-        "RSD",
-        "RUB",
-        "RWF",
-        "SAR",
-        "SBD",
-        "SCR",
-        "SDG",
-        "SEK",
-        "SGD",
-        "SHP",
-        "SLL",
-        "SOS",
-        "SRD",
-        "SSP",
-        "STD",
-        "SYP",
-        "SZL",
-        "THB",
-        "TJS",
-        "TMT",
-        "TND",
-        "TOP",
-        "TRY",
-        "TTD",
-        "TWD",
-        "TZS",
-        "UAH",
-        "UGX",
-        "USD",
-        "USN",
-        "USS",
-        "UYI",
-        "UYU",
-        "UZS",
-        "VEF",
-        "VND",
-        "VUV",
-        "WST",
-        "XAF",
-        "XCD",
-        "XDR",
-        "XFU",
-        "XOF",
-        "XPF",
-        "YER",
-        "ZAR",
-        "ZMW"
-    ],
-    "cities": [
-        "Derby Center",
-        "New Deal",
-        "Cienega Springs",
-        "Ransom Canyon",
-        "Burrton",
-        "Hoonah",
-        "Lucien",
-        "San Martin",
-        "Buffalo City",
-        "Skidaway Island",
-        "Kingsbridge",
-        "Berkhamsted",
-        "Bury",
-        "Brandwell",
-        "Campden",
-        "Plympton",
-        "Baldock",
-        "Northleach",
-        "Newstead"
-    ],
-    "states": [
-        "Alabama",
-        "Alaska",
-        "Arizona",
-        "Arkansas",
-        "California",
-        "Colorado",
-        "Connecticut",
-        "Delaware",
-        "Florida",
-        "Georgia",
-        "Hawaii",
-        "Idaho",
-        "Illinois",
-        "Indiana",
-        "Iowa",
-        "Kansas",
-        "Kentucky",
-        "Louisiana",
-        "Maine",
-        "Maryland",
-        "Massachusetts",
-        "Michigan",
-        "Minnesota",
-        "Mississippi",
-        "Missouri",
-        "Montana",
-        "Nebraska",
-        "Nevada",
-        "New Hampshire",
-        "New Jersey",
-        "New Mexico",
-        "New York",
-        "North Carolina",
-        "North Dakota",
-        "Ohio",
-        "Oklahoma",
-        "Oregon",
-        "Pennsylvania",
-        "Rhode Island",
-        "South Carolina",
-        "South Dakota",
-        "Tennessee",
-        "Texas",
-        "Utah",
-        "Vermont",
-        "Virginia",
-        "Washington",
-        "West Virginia",
-        "Wisconsin",
-        "Wyoming"
-    ],
-    "statesSmall": [
-        "AL",
-        "AK",
-        "AS",
-        "AZ",
-        "AR",
-        "CA",
-        "CO",
-        "CT",
-        "DE",
-        "DC",
-        "FM",
-        "FL",
-        "GA",
-        "GU",
-        "HI",
-        "ID",
-        "IL",
-        "IN",
-        "IA",
-        "KS",
-        "KY",
-        "LA",
-        "ME",
-        "MH",
-        "MD",
-        "MA",
-        "MI",
-        "MN",
-        "MS",
-        "MO",
-        "MT",
-        "NE",
-        "NV",
-        "NH",
-        "NJ",
-        "NM",
-        "NY",
-        "NC",
-        "ND",
-        "MP",
-        "OH",
-        "OK",
-        "OR",
-        "PW",
-        "PA",
-        "PR",
-        "RI",
-        "SC",
-        "SD",
-        "TN",
-        "TX",
-        "UT",
-        "VT",
-        "VA",
-        "VI",
-        "WA",
-        "DC",
-        "WV",
-        "WI",
-        "WY"
-    ]
-}`)
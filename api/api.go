@@ -0,0 +1,148 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/albertrdixon/transmon/logging"
+	"github.com/albertrdixon/transmon/metrics"
+	"github.com/zenazn/goji/web"
+	"golang.org/x/net/context"
+)
+
+var logger = logging.New("api")
+
+// Backend is what the daemon exposes to the api. The control calls only
+// queue work for the daemon, they return ErrBusy when a request of the same
+// kind is still waiting.
+type Backend interface {
+	Status() *Status
+	RefreshPort() error
+	RestartVPN() error
+	Clean() error
+}
+
+type Status struct {
+	BindIP      string     `json:"bind_ip"`
+	Port        int        `json:"port"`
+	Provider    string     `json:"provider"`
+	Server      string     `json:"server,omitempty"`
+	PortOpen    *bool      `json:"port_open"`
+	PortChecked time.Time  `json:"port_checked"`
+	PortUpdated time.Time  `json:"port_updated"`
+	Cleaned     time.Time  `json:"cleaned"`
+	Processes   []*Process `json:"processes"`
+	Tunnel      *Tunnel    `json:"tunnel,omitempty"`
+}
+
+// Tunnel is the state of the vpn, for openvpn as reported over its
+// management socket.
+type Tunnel struct {
+	Backend  string    `json:"backend"`
+	State    string    `json:"state"`
+	LocalIP  string    `json:"local_ip"`
+	RemoteIP string    `json:"remote_ip"`
+	Since    time.Time `json:"since"`
+	BytesIn  int64     `json:"bytes_in"`
+	BytesOut int64     `json:"bytes_out"`
+}
+
+type Process struct {
+	Name    string    `json:"name"`
+	PID     int       `json:"pid"`
+	Running bool      `json:"running"`
+	Started time.Time `json:"started"`
+	Uptime  string    `json:"uptime"`
+}
+
+type Busy string
+
+func (b Busy) Error() string {
+	return string(b) + " already pending"
+}
+
+// New returns the api router for b. With a token the control endpoints
+// answer 401 unless the request carries it as "Authorization: Bearer token".
+func New(b Backend, token string) *web.Mux {
+	m := web.New()
+	m.Get("/status", func(w http.ResponseWriter, r *http.Request) {
+		reply(w, http.StatusOK, b.Status())
+	})
+	m.Get("/metrics", metrics.Handler())
+	m.Post("/port/refresh", control(b.RefreshPort, token))
+	m.Post("/vpn/restart", control(b.RestartVPN, token))
+	m.Post("/cleaner/run", control(b.Clean, token))
+	return m
+}
+
+// ListenAndServe serves the api on addr until ctx is done.
+func ListenAndServe(addr string, h http.Handler, ctx context.Context) error {
+	l, er := net.Listen("tcp", addr)
+	if er != nil {
+		return er
+	}
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	logger.Infof("API listening on %v", l.Addr())
+	er = http.Serve(l, h)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return er
+}
+
+func control(fn func() error, token string) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		logger.Debugf("API %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		if !authorized(r, token) {
+			logger.Warnf("API %s %s from %s without a valid token", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			reply(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		switch er := fn().(type) {
+		case nil:
+			reply(w, http.StatusAccepted, map[string]string{"result": "queued"})
+		case Busy:
+			reply(w, http.StatusConflict, map[string]string{"error": er.Error()})
+		default:
+			reply(w, http.StatusInternalServerError, map[string]string{"error": er.Error()})
+		}
+	}
+}
+
+func authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// Loopback reports whether addr only listens on the loopback interface.
+func Loopback(addr string) bool {
+	host, _, er := net.SplitHostPort(addr)
+	if er != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func reply(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if er := json.NewEncoder(w).Encode(v); er != nil {
+		logger.Warnf("Failed to write API response: %v", er)
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBackend struct {
+	refreshed int
+}
+
+func (t *testBackend) Status() *Status {
+	open := true
+	return &Status{
+		BindIP:    "10.0.0.2",
+		Port:      1234,
+		PortOpen:  &open,
+		Processes: []*Process{{Name: "openvpn", PID: 42, Running: true}},
+	}
+}
+
+func (t *testBackend) RefreshPort() error {
+	t.refreshed++
+	if t.refreshed > 1 {
+		return Busy("port refresh")
+	}
+	return nil
+}
+
+func (t *testBackend) RestartVPN() error { return nil }
+func (t *testBackend) Clean() error      { return nil }
+
+func TestAPI(t *testing.T) {
+	is := assert.New(t)
+	b := new(testBackend)
+	server := httptest.NewServer(New(b, ""))
+	defer server.Close()
+
+	resp, er := http.Get(server.URL + "/status")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+	st := new(Status)
+	is.NoError(json.NewDecoder(resp.Body).Decode(st))
+	is.Equal("10.0.0.2", st.BindIP)
+	is.Equal(1234, st.Port)
+	is.True(*st.PortOpen)
+	is.Equal(42, st.Processes[0].PID)
+
+	resp, er = http.Post(server.URL+"/port/refresh", "", nil)
+	is.NoError(er)
+	is.Equal(http.StatusAccepted, resp.StatusCode)
+	resp, er = http.Post(server.URL+"/port/refresh", "", nil)
+	is.NoError(er)
+	is.Equal(http.StatusConflict, resp.StatusCode)
+
+	resp, er = http.Get(server.URL + "/vpn/restart")
+	is.NoError(er)
+	is.NotEqual(http.StatusAccepted, resp.StatusCode)
+}
+
+func TestAPIToken(t *testing.T) {
+	is := assert.New(t)
+	server := httptest.NewServer(New(new(testBackend), "secret"))
+	defer server.Close()
+
+	resp, er := http.Get(server.URL + "/status")
+	is.NoError(er)
+	is.Equal(http.StatusOK, resp.StatusCode, "status stays open")
+
+	resp, er = http.Post(server.URL+"/vpn/restart", "", nil)
+	is.NoError(er)
+	is.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	req, _ := http.NewRequest("POST", server.URL+"/vpn/restart", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	resp, er = http.DefaultClient.Do(req)
+	is.NoError(er)
+	is.Equal(http.StatusUnauthorized, resp.StatusCode)
+
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, er = http.DefaultClient.Do(req)
+	is.NoError(er)
+	is.Equal(http.StatusAccepted, resp.StatusCode)
+}
+
+func TestLoopback(t *testing.T) {
+	is := assert.New(t)
+	is.True(Loopback("127.0.0.1:8080"))
+	is.True(Loopback("localhost:8080"))
+	is.True(Loopback("[::1]:8080"))
+	is.False(Loopback(":8080"))
+	is.False(Loopback("0.0.0.0:8080"))
+	is.False(Loopback("192.168.1.2:8080"))
+}
@@ -0,0 +1,40 @@
+// Package atomicfile replaces files so readers see the old or the new
+// content and never a partial write.
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Write creates the directory of file when needed, writes data to a temp
+// file next to it, syncs it and renames it over file. The temp file is
+// removed when any step fails.
+func Write(file string, data []byte) (er error) {
+	dir := filepath.Dir(file)
+	if er := os.MkdirAll(dir, 0700); er != nil {
+		return er
+	}
+	f, er := ioutil.TempFile(dir, "."+filepath.Base(file))
+	if er != nil {
+		return er
+	}
+	defer func() {
+		if er != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	_, er = f.Write(data)
+	if er == nil {
+		er = f.Sync()
+	}
+	if e := f.Close(); er == nil {
+		er = e
+	}
+	if er != nil {
+		return er
+	}
+	return os.Rename(f.Name(), file)
+}
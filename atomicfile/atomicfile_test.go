@@ -0,0 +1,34 @@
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "state", "test.json")
+	is.NoError(Write(file, []byte("one")))
+	is.NoError(Write(file, []byte("two")))
+	data, er := ioutil.ReadFile(file)
+	is.NoError(er)
+	is.Equal("two", string(data))
+
+	left, _ := filepath.Glob(filepath.Join(dir, "state", ".test.json*"))
+	is.Empty(left)
+
+	// Renaming over a directory fails and must not leave the temp file.
+	is.Error(Write(filepath.Join(dir, "state"), []byte("three")))
+	left, _ = filepath.Glob(filepath.Join(dir, ".state*"))
+	is.Empty(left)
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Cleaner rule actions.
+const (
+	ActionRemove     = "remove"
+	ActionRemoveData = "remove-data"
+	ActionStop       = "stop"
+	ActionMove       = "move"
+	ActionLabel      = "label"
+)
+
+// CleanerRule applies Action to torrents matching every condition in Match.
+// Rules are checked in order and the first match wins.
+type CleanerRule struct {
+	Name     string     `json:"name"`
+	Match    *RuleMatch `json:"match"`
+	Action   string     `json:"action"`
+	Location string     `json:"location,omitempty"`
+	Label    string     `json:"label,omitempty"`
+}
+
+// RuleMatch conditions are all optional, unset ones match anything. Ratio,
+// SeedingTime, Age, Stalled and Errored are lower bounds.
+type RuleMatch struct {
+	Finished    *bool     `json:"finished,omitempty"`
+	Complete    *bool     `json:"complete,omitempty"`
+	Ratio       float64   `json:"ratio,omitempty"`
+	SeedingTime *duration `json:"seeding_time,omitempty"`
+	Age         *duration `json:"age,omitempty"`
+	Stalled     int       `json:"stalled,omitempty"`
+	Errored     int       `json:"errored,omitempty"`
+	Error       *Pattern  `json:"error,omitempty"`
+	DownloadDir *Pattern  `json:"download_dir,omitempty"`
+	Tracker     *Pattern  `json:"tracker,omitempty"`
+	Label       string    `json:"label,omitempty"`
+}
+
+// Pattern is a regular expression in the config file.
+type Pattern struct {
+	*regexp.Regexp
+}
+
+func (p *Pattern) UnmarshalJSON(b []byte) error {
+	var expr string
+	if er := json.Unmarshal(b, &expr); er != nil {
+		return er
+	}
+	re, er := regexp.Compile(expr)
+	if er != nil {
+		return er
+	}
+	p.Regexp = re
+	return nil
+}
+
+func (r *CleanerRule) Validate() error {
+	if r.Match == nil {
+		return fmt.Errorf("cleaner rule %q has no match", r.Name)
+	}
+	switch r.Action {
+	case ActionRemove, ActionRemoveData, ActionStop:
+	case ActionMove:
+		if r.Location == "" {
+			return fmt.Errorf("cleaner rule %q needs a location to move to", r.Name)
+		}
+	case ActionLabel:
+		if r.Label == "" {
+			return fmt.Errorf("cleaner rule %q needs a label to set", r.Name)
+		}
+	default:
+		return fmt.Errorf("cleaner rule %q has unknown action %q", r.Name, r.Action)
+	}
+	return nil
+}
+
+// defaultRules keep the cleaner's original behaviour: drop finished torrents
+// and torrents that errored or made no progress for three runs.
+func defaultRules() []*CleanerRule {
+	yes, no := true, false
+	return []*CleanerRule{
+		{Name: "finished", Match: &RuleMatch{Finished: &yes}, Action: ActionRemoveData},
+		{Name: "error", Match: &RuleMatch{Errored: 3}, Action: ActionRemoveData},
+		{Name: "stalled", Match: &RuleMatch{Complete: &no, Stalled: 3}, Action: ActionRemoveData},
+	}
+}
@@ -7,20 +7,60 @@ import (
 
 	"golang.org/x/net/context"
 
-	"github.com/albertrdixon/gearbox/logger"
+	"github.com/albertrdixon/transmon/logging"
 	"github.com/albertrdixon/transmon/pia"
 	"github.com/ghodss/yaml"
 	"github.com/imdario/mergo"
 	"github.com/satori/go.uuid"
 )
 
+var logger = logging.New("config")
+
 var (
 	conf = &Config{
 		PIA:          &PIA{URL: pia.GetPortForwardEndpoint(), ClientID: uuid.NewV4().String()},
-		Transmission: &Transmission{UID: 0, GID: 0},
+		Transmission: &Transmission{UID: 0, GID: 0, StopTimeout: &duration{Duration: 30 * time.Second}, Backups: 3},
+		VPN:          OpenVPNTunnel,
 		OpenVPN:      &OpenVPN{Tun: defaultDevice},
 		Timeout:      &duration{Duration: defaultDuration},
-		Cleaner:      &Cleaner{Enabled: false, Interval: &duration{Duration: 1 * time.Hour}},
+		Intervals: &Intervals{
+			Port:    &duration{Duration: 1 * time.Hour},
+			Check:   &duration{Duration: 5 * time.Minute},
+			Restart: &duration{Duration: 24 * time.Hour},
+		},
+		Cleaner: &Cleaner{Enabled: false, Interval: &duration{Duration: 1 * time.Hour}, StateFile: "/var/lib/transmon/cleaner.json", Rules: defaultRules()},
+		NATPMP:  &NATPMP{Gateway: "10.2.0.1", InternalPort: 1, Lifetime: &duration{Duration: 60 * time.Second}},
+		Firewall: &Firewall{
+			Enabled: false,
+			Backend: "iptables",
+			Local:   []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16"},
+		},
+		Health: &Health{
+			Enabled:  false,
+			Interval: &duration{Duration: 1 * time.Minute},
+			Timeout:  &duration{Duration: 10 * time.Second},
+			Failures: 3,
+			URL:      "http://connectivitycheck.gstatic.com/generate_204",
+			IPURL:    "https://api.ipify.org",
+		},
+		LeakCheck: &LeakCheck{
+			Enabled:  false,
+			Interval: &duration{Duration: 10 * time.Minute},
+			URL:      "https://api.ipify.org",
+			Action:   LeakAlert,
+		},
+		Failover: &Failover{Attempts: 3, StateFile: "/var/lib/transmon/failover.json"},
+		Notify:   &Notifications{Interval: &duration{Duration: 1 * time.Minute}},
+		Ingest:   &Ingest{Interval: &duration{Duration: 5 * time.Minute}, Timeout: &duration{Duration: 30 * time.Second}, StateFile: "/var/lib/transmon/ingest.json"},
+	}
+	piaOpenVPN = &PIAOpenVPN{
+		Protocol: "udp",
+		Cipher:   pia.AES128,
+		Dir:      "/var/lib/transmon/openvpn",
+	}
+	nextGen = &PIANextGen{
+		StateFile: "/var/lib/transmon/pia.json",
+		TokenURL:  pia.DefaultTokenURL,
 	}
 )
 
@@ -33,15 +73,16 @@ func Read(file string) (*Config, error) {
 	return read(file, info)
 }
 
-func ReadAndWatch(file string, ctx context.Context) (*Config, error) {
+func ReadAndWatch(file string, ctx context.Context) (*Watcher, error) {
 	c, er := Read(file)
 	if er != nil {
-		return c, er
+		return nil, er
 	}
 
+	w := newWatcher(c)
 	go func() {
 		logger.Debugf("Watching for config changes: config=%q", file)
-		t := time.NewTicker(5 * time.Second)
+		t := time.NewTicker(watchInterval)
 		defer t.Stop()
 
 		for {
@@ -49,32 +90,17 @@ func ReadAndWatch(file string, ctx context.Context) (*Config, error) {
 			case <-ctx.Done():
 				return
 			case <-t.C:
-				if er, ok := c.update(); er == nil && ok {
-					logger.Infof("Config updated")
+				u, er := w.reload()
+				if er != nil {
+					logger.Errorf("Failed to reload config: %v", er)
+				} else if u != nil {
+					logger.Infof("Config updated: %v", u.Changed)
 				}
 			}
 		}
 	}()
 
-	return c, nil
-}
-
-func (c *Config) update() (error, bool) {
-	info, er := os.Stat(c.file)
-	if er != nil {
-		return er, false
-	}
-
-	if info.ModTime().Equal(c.modTime) {
-		return nil, false
-	}
-
-	nc, er := read(c.file, info)
-	if er == nil {
-		c = nc
-		return nil, true
-	}
-	return er, false
+	return w, nil
 }
 
 func read(file string, info os.FileInfo) (*Config, error) {
@@ -91,10 +117,39 @@ func read(file string, info os.FileInfo) (*Config, error) {
 
 	c.file = file
 	c.modTime = info.ModTime()
-	return c, mergo.Merge(c, conf)
+	if er := mergo.Merge(c, conf); er != nil {
+		return c, er
+	}
+	if c.PIA.NextGen != nil {
+		if er := mergo.Merge(c.PIA.NextGen, nextGen); er != nil {
+			return c, er
+		}
+	}
+	if c.PIA.OpenVPN != nil {
+		if er := mergo.Merge(c.PIA.OpenVPN, piaOpenVPN); er != nil {
+			return c, er
+		}
+	}
+	if wg := c.WireGuard; wg != nil {
+		if wg.Mode == "" {
+			wg.Mode = WGQuick
+		}
+		if wg.Device == "" {
+			wg.Device = wgDevice(wg.Config)
+		}
+	}
+	if c.Provider == "" {
+		c.Provider = defaultProvider
+		if c.PIA.NextGen != nil {
+			c.Provider = defaultProvider + "-nextgen"
+		}
+	}
+	return c, c.Validate()
 }
 
 const (
 	defaultDuration = 5 * time.Minute
 	defaultDevice   = "tun0"
+	defaultProvider = "pia"
+	watchInterval   = 5 * time.Second
 )
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,7 +19,185 @@ func TestReadConfig(t *testing.T) {
 	is.NoError(er)
 	is.Equal("username", c.Transmission.User)
 	is.Equal("tun3", c.OpenVPN.Tun)
+	is.Equal("pia", c.Provider)
+	is.Equal(OpenVPNTunnel, c.VPN)
+	is.Equal("tun3", c.Device())
 	is.EqualValues(10*time.Minute, c.Timeout.Duration)
+	is.Equal(30*time.Minute, c.Intervals.Port.Duration)
+	is.Equal(5*time.Minute, c.Intervals.Check.Duration)
+	is.Equal(&Schedule{Hour: 4, Zone: "Local"}, c.Intervals.RestartAt)
 	is.True(c.Cleaner.Enabled)
 	is.Equal(3*time.Hour, c.Cleaner.Interval.Duration)
+	is.Equal("/tmp/transmon/cleaner.json", c.Cleaner.StateFile)
+	is.True(c.Health.Enabled)
+	is.Equal(2*time.Minute, c.Health.Interval.Duration)
+	is.Equal(10*time.Second, c.Health.Timeout.Duration)
+	is.Equal(3, c.Health.Failures)
+	is.Equal("203.0.113.7", c.Health.HomeIP)
+	is.NotEmpty(c.Health.URL)
+	if is.Len(c.Cleaner.Rules, 4) {
+		r := c.Cleaner.Rules[0]
+		is.Equal("seeded", r.Name)
+		is.Equal(ActionRemove, r.Action)
+		is.Equal(2.0, r.Match.Ratio)
+		is.Equal(72*time.Hour, r.Match.SeedingTime.Duration)
+		is.True(c.Cleaner.Rules[1].Match.Error.MatchString("Unregistered Torrent"))
+		is.Equal("/media/movies", c.Cleaner.Rules[3].Location)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	is := assert.New(t)
+
+	orig, er := ioutil.ReadFile("examples/config.yml")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	f, er := ioutil.TempFile("", "transmon-config")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	defer os.Remove(f.Name())
+	f.Write(orig)
+	f.Close()
+
+	c, er := Read(f.Name())
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	w := newWatcher(c)
+	updates := w.Subscribe()
+
+	u, er := w.reload()
+	is.NoError(er)
+	is.Nil(u)
+
+	changed := strings.Replace(string(orig), "device: tun3", "device: tun4", 1)
+	is.NoError(ioutil.WriteFile(f.Name(), []byte(changed), 0644))
+	later := time.Now().Add(time.Minute)
+	is.NoError(os.Chtimes(f.Name(), later, later))
+
+	u, er = w.reload()
+	if !is.NoError(er) || !is.NotNil(u) {
+		t.FailNow()
+	}
+	is.True(u.Has(OpenVPNSection))
+	is.False(u.Has(CleanerSection))
+	is.Equal("tun4", w.Config().OpenVPN.Tun)
+	is.Equal("tun3", u.Old.OpenVPN.Tun)
+
+	select {
+	case got := <-updates:
+		is.Equal(u, got)
+	default:
+		t.Error("Subscriber was not notified")
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	is := assert.New(t)
+
+	s, er := ParseSchedule("04:30 UTC")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	now := time.Date(2016, 1, 2, 3, 0, 0, 0, time.UTC)
+	is.Equal(time.Date(2016, 1, 2, 4, 30, 0, 0, time.UTC), s.Next(now))
+	now = time.Date(2016, 1, 2, 4, 30, 0, 0, time.UTC)
+	is.Equal(time.Date(2016, 1, 3, 4, 30, 0, 0, time.UTC), s.Next(now))
+
+	for _, bad := range []string{"", "4", "24:00", "04:61", "04:00 Nowhere/Atall", "04:00 UTC extra"} {
+		_, er := ParseSchedule(bad)
+		is.Error(er, bad)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	is := assert.New(t)
+	c, er := Read("examples/config.yml")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	is.NoError(c.Validate())
+
+	c.Intervals = &Intervals{Port: &duration{time.Second}, Check: c.Intervals.Check, Restart: c.Intervals.Restart}
+	is.Error(c.Validate())
+	c.Intervals.Port = &duration{time.Hour}
+	c.LeakCheck = &LeakCheck{Interval: &duration{time.Minute}, Action: "panic"}
+	is.Error(c.Validate())
+	c.LeakCheck.Action = LeakRestart
+	is.NoError(c.Validate())
+
+	is.Equal("iptables", c.Firewall.Backend)
+	is.NoError(c.Firewall.Validate())
+	c.VPN = WireGuardTunnel
+	is.Error(c.Validate())
+	c.WireGuard = &WireGuard{Config: "/etc/wireguard/wg0.conf", Device: "wg0", Mode: WGQuick}
+	is.NoError(c.Validate())
+	is.Equal("wg0", c.Device())
+	c.VPN = "ipsec"
+	is.Error(c.Validate())
+
+	c.VPN = OpenVPNTunnel
+	c.Failover = &Failover{Attempts: 0}
+	is.Error(c.Validate())
+	c.Failover = &Failover{Attempts: 1}
+	c.Notify = &Notifications{Interval: &duration{time.Minute}, Sinks: []*Sink{{Type: SinkWebhook, URL: "http://localhost/hook", Events: []string{EventFatal}}}}
+	is.NoError(c.Validate())
+	is.True(c.Notify.Sinks[0].Wants(EventFatal))
+	is.False(c.Notify.Sinks[0].Wants(EventPortChanged))
+	c.Ingest = &Ingest{Interval: &duration{5 * time.Minute}, Timeout: &duration{30 * time.Second}, Feeds: []*Feed{{URL: "http://example.com/shows.rss", Include: []string{"("}}}}
+	is.Error(c.Validate())
+	c.Ingest.Feeds[0].Include = []string{"720p"}
+	is.NoError(c.Validate())
+	is.True(c.Ingest.Enabled())
+	c.Ingest.Interval = &duration{time.Second}
+	is.Error(c.Validate())
+	c.Ingest.Interval = &duration{5 * time.Minute}
+	c.Notify.Sinks[0].Events = []string{"tea_time"}
+	is.Error(c.Validate())
+	c.Notify.Sinks = []*Sink{{Type: SinkSMTP, Host: "localhost:25"}}
+	is.Error(c.Validate())
+	c.Notify.Sinks = nil
+	c.Notify.Templates = map[string]string{EventFatal: "{{.Message"}
+	is.Error(c.Validate())
+
+	is.Equal(float64(2), c.Transmission.Settings["encryption"])
+	is.NoError(validateDesired(map[string]interface{}{"speed-limit-up": 100, "download-dir": "/data", "ratio-limit": 1.5}))
+	is.Error(validateDesired(map[string]interface{}{"peer-port": 51413}), "transmon manages the peer port")
+	is.Error(validateDesired(map[string]interface{}{"speed-limit-up": "fast"}))
+	is.NoError(validateDesired(map[string]interface{}{"umask": 18, "peer-socket-tos": "lowcost"}), "unknown settings pass through")
+	is.Error(validateDesired(map[string]interface{}{"encryption": 3}))
+
+	is.Error((&Firewall{Backend: "pf"}).Validate())
+	is.Error((&Firewall{Backend: "nftables", Local: []string{"192.168.1.1"}}).Validate())
+}
+
+func TestWithServer(t *testing.T) {
+	is := assert.New(t)
+	c, er := Read("examples/config.yml")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	is.Equal(3, c.Failover.Attempts)
+
+	cmd := c.OpenVPN.Command
+	s := c.WithServer("/etc/openvpn/nl.ovpn").WithServer("/etc/openvpn/se.ovpn")
+	is.Equal(cmd+" --config /etc/openvpn/se.ovpn", s.OpenVPN.Command)
+	is.Equal(cmd, c.OpenVPN.Command)
+
+	c.OpenVPN = &OpenVPN{Command: "openvpn --config /etc/openvpn/us.ovpn --remote us.example.com 1194 udp --verb 3"}
+	is.Equal("openvpn --verb 3 --config /etc/openvpn/se.ovpn", c.WithServer("/etc/openvpn/se.ovpn").OpenVPN.Command)
+	c.OpenVPN.Command = "openvpn /etc/openvpn/us.ovpn"
+	is.Equal("openvpn --config /etc/openvpn/se.ovpn", c.WithServer("/etc/openvpn/se.ovpn").OpenVPN.Command)
+
+	c.PIA.OpenVPN = &PIAOpenVPN{Region: "nl"}
+	is.Equal("swiss", c.WithServer("swiss").PIA.OpenVPN.Region)
+	is.Equal("nl", c.PIA.OpenVPN.Region)
+
+	c.VPN = WireGuardTunnel
+	c.WireGuard = &WireGuard{Config: "/etc/wireguard/wg0.conf", Device: "wg0", Mode: WGQuick}
+	is.Equal("mullvad-se", c.WithServer("/etc/wireguard/mullvad-se.conf").Device())
+	c.WireGuard.Mode = WGSetconf
+	is.Equal("wg0", c.WithServer("/etc/wireguard/mullvad-se.conf").Device())
 }
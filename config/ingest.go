@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Ingest adds torrents from watch directories and RSS or Atom feeds. Both
+// are checked every Interval, feeds and their torrents are downloaded with
+// Timeout. What was added is kept in StateFile so it is never added twice.
+type Ingest struct {
+	Interval  *duration   `json:"interval"`
+	Timeout   *duration   `json:"timeout"`
+	StateFile string      `json:"state_file"`
+	Watch     []*WatchDir `json:"watch,omitempty"`
+	Feeds     []*Feed     `json:"feeds,omitempty"`
+}
+
+// WatchDir is a directory of .torrent and .magnet files. Torrents are added
+// to DownloadDir, or Transmission's download dir when it is empty.
+type WatchDir struct {
+	Path        string `json:"path"`
+	DownloadDir string `json:"download_dir,omitempty"`
+}
+
+// Feed is an RSS or Atom feed. Items whose title matches one of Include
+// (any title when it is empty) and none of Exclude are added to
+// DownloadDir.
+type Feed struct {
+	URL         string   `json:"url"`
+	Include     []string `json:"include,omitempty"`
+	Exclude     []string `json:"exclude,omitempty"`
+	DownloadDir string   `json:"download_dir,omitempty"`
+}
+
+// Enabled reports whether there is anything to watch.
+func (i *Ingest) Enabled() bool {
+	return len(i.Watch) > 0 || len(i.Feeds) > 0
+}
+
+func (i *Ingest) Validate() error {
+	for n, w := range i.Watch {
+		if w.Path == "" {
+			return fmt.Errorf("ingest.watch[%d] needs a path", n)
+		}
+	}
+	for n, f := range i.Feeds {
+		if f.URL == "" {
+			return fmt.Errorf("ingest.feeds[%d] needs a url", n)
+		}
+		for _, p := range append(append([]string(nil), f.Include...), f.Exclude...) {
+			if _, er := regexp.Compile(p); er != nil {
+				return fmt.Errorf("ingest.feeds[%d]: %v", n, er)
+			}
+		}
+	}
+	return nil
+}
@@ -14,3 +14,12 @@ func (d *duration) UnmarshalJSON(p []byte) error {
 	d.Duration = t
 	return nil
 }
+
+func (s *Schedule) UnmarshalJSON(p []byte) error {
+	sc, er := ParseSchedule(string(bytes.Trim(p, `"`)))
+	if er != nil {
+		return er
+	}
+	*s = *sc
+	return nil
+}
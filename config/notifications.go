@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// Notification events.
+const (
+	EventPortChanged     = "port_changed"
+	EventVPNRestarted    = "vpn_restarted"
+	EventTorrentFinished = "torrent_finished"
+	EventTorrentCleaned  = "torrent_cleaned"
+	EventFatal           = "fatal"
+)
+
+var Events = []string{EventPortChanged, EventVPNRestarted, EventTorrentFinished, EventTorrentCleaned, EventFatal}
+
+// Notification sink types.
+const (
+	SinkWebhook  = "webhook"
+	SinkPushover = "pushover"
+	SinkGotify   = "gotify"
+	SinkSMTP     = "smtp"
+)
+
+// Notifications sends events to every sink subscribed to them. Templates
+// replace the message of an event for all sinks, a sink's Template replaces
+// it for that sink. Finished torrents are looked for every Interval.
+type Notifications struct {
+	Interval  *duration         `json:"interval"`
+	Templates map[string]string `json:"templates,omitempty"`
+	Sinks     []*Sink           `json:"sinks,omitempty"`
+}
+
+// Sink is one place notifications go. Events limits it to those events, it
+// gets all of them when empty. Which other fields are used depends on Type:
+//
+//	webhook:  URL, Headers
+//	pushover: Token, User, Priority and URL to override the api
+//	gotify:   URL, Token, Priority
+//	smtp:     Host (host:port), Username, Password, From, To
+type Sink struct {
+	Type     string            `json:"type"`
+	Events   []string          `json:"events,omitempty"`
+	Template string            `json:"template,omitempty"`
+	URL      string            `json:"url,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Token    string            `json:"token,omitempty"`
+	User     string            `json:"user,omitempty"`
+	Priority int               `json:"priority,omitempty"`
+	Host     string            `json:"host,omitempty"`
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	From     string            `json:"from,omitempty"`
+	To       []string          `json:"to,omitempty"`
+}
+
+// Wants reports whether s is subscribed to event.
+func (s *Sink) Wants(event string) bool {
+	if len(s.Events) < 1 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifications) Validate() error {
+	for event, text := range n.Templates {
+		if !knownEvent(event) {
+			return fmt.Errorf("notifications.templates has unknown event %q", event)
+		}
+		if _, er := template.New(event).Parse(text); er != nil {
+			return fmt.Errorf("notifications.templates.%s: %v", event, er)
+		}
+	}
+	for i, s := range n.Sinks {
+		if er := s.Validate(); er != nil {
+			return fmt.Errorf("notifications.sinks[%d]: %v", i, er)
+		}
+	}
+	return nil
+}
+
+func (s *Sink) Validate() error {
+	for _, e := range s.Events {
+		if !knownEvent(e) {
+			return fmt.Errorf("unknown event %q", e)
+		}
+	}
+	if _, er := template.New(s.Type).Parse(s.Template); er != nil {
+		return er
+	}
+	switch s.Type {
+	case SinkWebhook:
+		if s.URL == "" {
+			return fmt.Errorf("webhook needs a url")
+		}
+	case SinkPushover:
+		if s.Token == "" || s.User == "" {
+			return fmt.Errorf("pushover needs a token and user")
+		}
+	case SinkGotify:
+		if s.URL == "" || s.Token == "" {
+			return fmt.Errorf("gotify needs a url and token")
+		}
+	case SinkSMTP:
+		if s.Host == "" || s.From == "" || len(s.To) < 1 {
+			return fmt.Errorf("smtp needs a host, from and to")
+		}
+	default:
+		return fmt.Errorf("unknown sink type %q", s.Type)
+	}
+	return nil
+}
+
+func knownEvent(e string) bool {
+	for _, k := range Events {
+		if k == e {
+			return true
+		}
+	}
+	return false
+}
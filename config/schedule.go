@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Schedule is a daily wall clock time such as "04:00", "04:00 local" or
+// "23:30 Europe/Berlin". Without a zone the local time zone is used.
+type Schedule struct {
+	Hour, Minute int
+	Zone         string
+}
+
+func ParseSchedule(s string) (*Schedule, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 1 || len(fields) > 2 {
+		return nil, fmt.Errorf("Bad schedule %q, want \"HH:MM [zone]\"", s)
+	}
+
+	sc := &Schedule{Zone: "Local"}
+	if _, er := fmt.Sscanf(fields[0], "%d:%d", &sc.Hour, &sc.Minute); er != nil {
+		return nil, fmt.Errorf("Bad schedule %q: %v", s, er)
+	}
+	if sc.Hour < 0 || sc.Hour > 23 || sc.Minute < 0 || sc.Minute > 59 {
+		return nil, fmt.Errorf("Bad schedule %q: time out of range", s)
+	}
+	if len(fields) == 2 {
+		sc.Zone = fields[1]
+		if strings.EqualFold(sc.Zone, "local") {
+			sc.Zone = "Local"
+		}
+	}
+	if _, er := sc.location(); er != nil {
+		return nil, fmt.Errorf("Bad schedule %q: %v", s, er)
+	}
+	return sc, nil
+}
+
+// Next returns the first time after t the schedule fires.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc, er := s.location()
+	if er != nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	n := time.Date(t.Year(), t.Month(), t.Day(), s.Hour, s.Minute, 0, 0, loc)
+	if !n.After(t) {
+		n = time.Date(t.Year(), t.Month(), t.Day()+1, s.Hour, s.Minute, 0, 0, loc)
+	}
+	return n
+}
+
+func (s *Schedule) String() string {
+	return fmt.Sprintf("%02d:%02d %s", s.Hour, s.Minute, s.Zone)
+}
+
+func (s *Schedule) location() (*time.Location, error) {
+	switch s.Zone {
+	case "", "Local":
+		return time.Local, nil
+	case "UTC":
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Zone)
+}
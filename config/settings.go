@@ -0,0 +1,166 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+)
+
+// Settings.json keys transmon sets itself.
+const (
+	BindKey    = "bind-address-ipv4"
+	PortKey    = "peer-port"
+	ForwardKey = "port-forwarding-enabled"
+	RandomKey  = "peer-port-random-on-start"
+)
+
+// Managed are the Transmission settings transmon sets itself, they cannot
+// be set in transmission.settings.
+var Managed = []string{BindKey, PortKey, ForwardKey, RandomKey}
+
+// Value kinds of settingTypes.
+const (
+	kindBool   = "bool"
+	kindNumber = "number"
+	kindString = "string"
+)
+
+// settingTypes are the kinds of the Transmission settings.json keys transmon
+// knows. Keys missing here are written unchecked.
+var settingTypes = map[string]string{
+	"alt-speed-down":               kindNumber,
+	"alt-speed-enabled":            kindBool,
+	"alt-speed-time-begin":         kindNumber,
+	"alt-speed-time-day":           kindNumber,
+	"alt-speed-time-enabled":       kindBool,
+	"alt-speed-time-end":           kindNumber,
+	"alt-speed-up":                 kindNumber,
+	"bind-address-ipv4":            kindString,
+	"bind-address-ipv6":            kindString,
+	"blocklist-enabled":            kindBool,
+	"blocklist-url":                kindString,
+	"cache-size-mb":                kindNumber,
+	"dht-enabled":                  kindBool,
+	"download-dir":                 kindString,
+	"download-queue-enabled":       kindBool,
+	"download-queue-size":          kindNumber,
+	"encryption":                   kindNumber,
+	"idle-seeding-limit":           kindNumber,
+	"idle-seeding-limit-enabled":   kindBool,
+	"incomplete-dir":               kindString,
+	"incomplete-dir-enabled":       kindBool,
+	"lpd-enabled":                  kindBool,
+	"message-level":                kindNumber,
+	"peer-congestion-algorithm":    kindString,
+	"peer-limit-global":            kindNumber,
+	"peer-limit-per-torrent":       kindNumber,
+	"peer-port":                    kindNumber,
+	"peer-port-random-on-start":    kindBool,
+	"peer-port-random-high":        kindNumber,
+	"peer-port-random-low":         kindNumber,
+	"pex-enabled":                  kindBool,
+	"port-forwarding-enabled":      kindBool,
+	"preallocation":                kindNumber,
+	"prefetch-enabled":             kindBool,
+	"queue-stalled-enabled":        kindBool,
+	"queue-stalled-minutes":        kindNumber,
+	"ratio-limit":                  kindNumber,
+	"ratio-limit-enabled":          kindBool,
+	"rename-partial-files":         kindBool,
+	"rpc-authentication-required":  kindBool,
+	"rpc-bind-address":             kindString,
+	"rpc-enabled":                  kindBool,
+	"rpc-host-whitelist":           kindString,
+	"rpc-host-whitelist-enabled":   kindBool,
+	"rpc-password":                 kindString,
+	"rpc-port":                     kindNumber,
+	"rpc-url":                      kindString,
+	"rpc-username":                 kindString,
+	"rpc-whitelist":                kindString,
+	"rpc-whitelist-enabled":        kindBool,
+	"script-torrent-done-enabled":  kindBool,
+	"script-torrent-done-filename": kindString,
+	"seed-queue-enabled":           kindBool,
+	"seed-queue-size":              kindNumber,
+	"speed-limit-down":             kindNumber,
+	"speed-limit-down-enabled":     kindBool,
+	"speed-limit-up":               kindNumber,
+	"speed-limit-up-enabled":       kindBool,
+	"start-added-torrents":         kindBool,
+	"trash-original-torrent-files": kindBool,
+	"upload-slots-per-torrent":     kindNumber,
+	"utp-enabled":                  kindBool,
+	"watch-dir":                    kindString,
+	"watch-dir-enabled":            kindBool,
+}
+
+// ValidateSettings checks the settings of s that transmon knows have a
+// value of the right kind. Unknown settings pass.
+func ValidateSettings(s map[string]interface{}) error {
+	for k, v := range s {
+		kind, ok := settingTypes[k]
+		if !ok {
+			continue
+		}
+		if got := kindOf(v); got != kind {
+			return fmt.Errorf("transmission setting %q must be a %s, got %v", k, kind, v)
+		}
+	}
+	if ip, ok := s[BindKey].(string); ok && net.ParseIP(ip).To4() == nil {
+		return fmt.Errorf("transmission setting %q must be an ipv4 address, got %q", BindKey, ip)
+	}
+	for _, k := range []string{PortKey, "rpc-port"} {
+		if p, ok := number(s[k]); ok && (p < 1 || p > 65535 || p != float64(int(p))) {
+			return fmt.Errorf("transmission setting %q must be a port, got %v", k, s[k])
+		}
+	}
+	if e, ok := number(s["encryption"]); ok && (e < 0 || e > 2) {
+		return fmt.Errorf("transmission setting \"encryption\" must be 0, 1 or 2, got %v", e)
+	}
+	return nil
+}
+
+// kindOf is the json kind of v.
+func kindOf(v interface{}) string {
+	b, er := json.Marshal(v)
+	if er != nil {
+		return ""
+	}
+	var out interface{}
+	if er := json.Unmarshal(b, &out); er != nil {
+		return ""
+	}
+	switch out.(type) {
+	case bool:
+		return kindBool
+	case float64:
+		return kindNumber
+	case string:
+		return kindString
+	}
+	return fmt.Sprintf("%T", out)
+}
+
+func number(v interface{}) (float64, bool) {
+	if v == nil || kindOf(v) != kindNumber {
+		return 0, false
+	}
+	b, _ := json.Marshal(v)
+	var n float64
+	return n, json.Unmarshal(b, &n) == nil
+}
+
+// validateDesired checks the settings of the transmission section.
+func validateDesired(s map[string]interface{}) error {
+	for _, k := range Managed {
+		if _, ok := s[k]; ok {
+			return fmt.Errorf("transmission.settings cannot set %q, transmon manages it", k)
+		}
+	}
+	for k := range s {
+		if _, ok := settingTypes[k]; !ok {
+			logger.Warnf("Unknown transmission setting %q, writing it unchecked", k)
+		}
+	}
+	return ValidateSettings(s)
+}
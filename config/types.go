@@ -1,38 +1,164 @@
 package config
 
 import (
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/albertrdixon/gearbox/url"
 )
 
 type Config struct {
-	Timeout      *duration `json:"timeout,omitempty"`
+	Timeout      *duration  `json:"timeout,omitempty"`
+	Intervals    *Intervals `json:"intervals,omitempty"`
 	Cleaner      *Cleaner
-	PIA          *PIA          `json:"pia"`
-	Transmission *Transmission `json:"transmission"`
-	OpenVPN      *OpenVPN      `json:"openvpn"`
+	Provider     string         `json:"provider"`
+	Static       *StaticPort    `json:"static,omitempty"`
+	PortCommand  *PortCommand   `json:"port_command,omitempty"`
+	NATPMP       *NATPMP        `json:"natpmp,omitempty"`
+	PIA          *PIA           `json:"pia"`
+	Transmission *Transmission  `json:"transmission"`
+	VPN          string         `json:"vpn"`
+	OpenVPN      *OpenVPN       `json:"openvpn"`
+	WireGuard    *WireGuard     `json:"wireguard,omitempty"`
+	Firewall     *Firewall      `json:"firewall"`
+	Health       *Health        `json:"health"`
+	LeakCheck    *LeakCheck     `json:"leak_check"`
+	Failover     *Failover      `json:"failover"`
+	Notify       *Notifications `json:"notifications"`
+	Ingest       *Ingest        `json:"ingest"`
+	API          *API           `json:"api,omitempty"`
 	modTime      time.Time
 	file         string
+	base         *Config
+}
+
+type Intervals struct {
+	Port      *duration `json:"port"`
+	Check     *duration `json:"check"`
+	Restart   *duration `json:"restart"`
+	RestartAt *Schedule `json:"restart_at,omitempty"`
+}
+
+// NextRestart returns when the VPN should be restarted next, either at the
+// daily restart_at time or one restart interval from now.
+func (i *Intervals) NextRestart(now time.Time) time.Time {
+	if i.RestartAt != nil {
+		return i.RestartAt.Next(now)
+	}
+	return now.Add(i.Restart.Duration)
 }
 
 type Cleaner struct {
-	Enabled  bool
-	Interval *duration
+	Enabled   bool
+	Interval  *duration
+	StateFile string         `json:"state_file"`
+	Rules     []*CleanerRule `json:"rules,omitempty"`
+}
+
+// Firewall is the kill switch. Transmission may only reach the network
+// through the tunnel, the VPN endpoints and the local networks.
+type Firewall struct {
+	Enabled   bool     `json:"enabled"`
+	Backend   string   `json:"backend"`
+	Local     []string `json:"local_networks"`
+	Endpoints []string `json:"endpoints,omitempty"`
+}
+
+// Health probes the tunnel every Interval and restarts the VPN after Failures
+// checks in a row fail. Each probe gives up after Timeout. Empty Ping, URL or
+// HomeIP skip that probe.
+type Health struct {
+	Enabled  bool      `json:"enabled"`
+	Interval *duration `json:"interval"`
+	Timeout  *duration `json:"timeout"`
+	Failures int       `json:"failures"`
+	Ping     string    `json:"ping"`
+	URL      string    `json:"url"`
+	IPURL    string    `json:"ip_url"`
+	HomeIP   string    `json:"home_ip"`
+}
+
+// LeakCheck compares the public ip seen through the tunnel with the one seen
+// through the default route every Interval. Action is alert or restart.
+type LeakCheck struct {
+	Enabled  bool      `json:"enabled"`
+	Interval *duration `json:"interval"`
+	URL      string    `json:"url"`
+	Action   string    `json:"action"`
+}
+
+// Failover moves to the next of Servers after Attempts connects or port
+// forwards in a row fail. A server is a pia.openvpn region, a wireguard
+// config or, for plain openvpn, a config file added to openvpn.command.
+// How each server did is kept in StateFile.
+type Failover struct {
+	Servers   []string `json:"servers,omitempty"`
+	Attempts  int      `json:"attempts"`
+	StateFile string   `json:"state_file"`
+}
+
+const (
+	LeakAlert   = "alert"
+	LeakRestart = "restart"
+)
+
+type StaticPort struct {
+	Port int `json:"port"`
+}
+
+type PortCommand struct {
+	Command string `json:"command"`
+}
+
+type NATPMP struct {
+	Gateway      string    `json:"gateway"`
+	InternalPort int       `json:"internal_port"`
+	Lifetime     *duration `json:"lifetime"`
 }
 
 type PIA struct {
-	User     string   `json:"username"`
-	Pass     string   `json:"password"`
-	ClientID string   `json:"client_id"`
-	URL      *url.URL `json:"url"`
+	User     string      `json:"username"`
+	Pass     string      `json:"password"`
+	ClientID string      `json:"client_id"`
+	URL      *url.URL    `json:"url"`
+	NextGen  *PIANextGen `json:"next_gen,omitempty"`
+	OpenVPN  *PIAOpenVPN `json:"openvpn,omitempty"`
 }
 
+// PIAOpenVPN has transmon write the openvpn config for a PIA region into
+// Dir instead of using a hand written one.
+type PIAOpenVPN struct {
+	Region     string `json:"region"`
+	Protocol   string `json:"protocol"`
+	Cipher     string `json:"cipher"`
+	CA         string `json:"ca"`
+	Dir        string `json:"dir"`
+	RegionsURL string `json:"regions_url,omitempty"`
+}
+
+type PIANextGen struct {
+	Hostname  string `json:"hostname"`
+	Gateway   string `json:"gateway"`
+	CA        string `json:"ca"`
+	StateFile string `json:"state_file"`
+	TokenURL  string `json:"token_url"`
+}
+
+// Transmission is sent SIGTERM to stop and killed when it has not exited
+// after StopTimeout. With LiveSettings a new port is set over rpc and
+// Transmission is only restarted when its bind address changes. Backups
+// previous versions of Config are kept whenever transmon rewrites it.
+// Settings are settings.json keys transmon keeps Transmission at.
 type Transmission struct {
-	Command          string `json:"command"`
-	UID              int    `json:"uid"`
-	GID              int    `json:"gid"`
-	Config           string `json:"config"`
+	Command          string                 `json:"command"`
+	UID              int                    `json:"uid"`
+	GID              int                    `json:"gid"`
+	Config           string                 `json:"config"`
+	StopTimeout      *duration              `json:"stop_timeout"`
+	LiveSettings     bool                   `json:"live_settings"`
+	Backups          int                    `json:"settings_backups"`
+	Settings         map[string]interface{} `json:"settings"`
 	*TransmissionRPC `json:"rpc"`
 }
 
@@ -42,9 +168,102 @@ type TransmissionRPC struct {
 	Pass string   `json:"password"`
 }
 
+// Tunnel backends for Config.VPN.
+const (
+	OpenVPNTunnel   = "openvpn"
+	WireGuardTunnel = "wireguard"
+)
+
+// Device is the tunnel interface Transmission is bound to.
+func (c *Config) Device() string {
+	if c.VPN == WireGuardTunnel {
+		return c.WireGuard.Device
+	}
+	return c.OpenVPN.Tun
+}
+
+// WithServer is a copy of c connecting to the failover server s.
+func (c *Config) WithServer(s string) *Config {
+	if c.base != nil {
+		c = c.base
+	}
+	n := *c
+	n.base = c
+	switch {
+	case c.VPN == WireGuardTunnel:
+		wg := *c.WireGuard
+		wg.Config = s
+		if wg.Mode == WGQuick {
+			wg.Device = wgDevice(s)
+		}
+		n.WireGuard = &wg
+	case c.PIA.OpenVPN != nil:
+		p, o := *c.PIA, *c.PIA.OpenVPN
+		o.Region = s
+		p.OpenVPN = &o
+		n.PIA = &p
+	default:
+		o := *c.OpenVPN
+		o.Command = withoutServer(o.Command)
+		if o.Command == "" {
+			o.Command = "openvpn"
+		}
+		o.Command += " --config " + s
+		n.OpenVPN = &o
+	}
+	return &n
+}
+
+// withoutServer drops the --config and --remote options of an openvpn
+// command, and a config file given as its only argument, so a failover
+// server replaces them.
+func withoutServer(cmd string) string {
+	args := strings.Fields(cmd)
+	if len(args) == 2 && !strings.HasPrefix(args[1], "--") {
+		return args[0]
+	}
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--config" || args[i] == "--remote" {
+			for i+1 < len(args) && !strings.HasPrefix(args[i+1], "--") {
+				i++
+			}
+			continue
+		}
+		out = append(out, args[i])
+	}
+	return strings.Join(out, " ")
+}
+
+// wgDevice is the interface wg-quick names after conf.
+func wgDevice(conf string) string {
+	return strings.TrimSuffix(filepath.Base(conf), ".conf")
+}
+
+// WireGuard brings up Config with wg-quick, or in wg mode with ip and wg
+// setconf and a routing table only used by traffic from the tunnel address.
+// Device defaults to the name of Config without .conf.
+type WireGuard struct {
+	Config string `json:"config"`
+	Device string `json:"device"`
+	Mode   string `json:"mode"`
+}
+
+const (
+	WGQuick   = "wg-quick"
+	WGSetconf = "wg"
+)
+
 type OpenVPN struct {
-	Tun     string `json:"device"`
-	Command string `json:"command"`
+	Tun        string `json:"device"`
+	Command    string `json:"command"`
+	Management string `json:"management,omitempty"`
+}
+
+type API struct {
+	Listen string `json:"listen"`
+	// Token is required as a bearer token by the control endpoints when set.
+	Token string `json:"token,omitempty"`
 }
 
 type duration struct {
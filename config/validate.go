@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/albertrdixon/transmon/pia"
+)
+
+var minimums = []struct {
+	name string
+	get  func(*Config) *duration
+	min  time.Duration
+}{
+	{"timeout", func(c *Config) *duration { return c.Timeout }, 10 * time.Second},
+	{"intervals.port", func(c *Config) *duration { return c.Intervals.Port }, time.Minute},
+	{"intervals.check", func(c *Config) *duration { return c.Intervals.Check }, 30 * time.Second},
+	{"intervals.restart", func(c *Config) *duration { return c.Intervals.Restart }, 10 * time.Minute},
+	{"cleaner.interval", func(c *Config) *duration { return c.Cleaner.Interval }, time.Minute},
+	{"health.interval", func(c *Config) *duration { return c.Health.Interval }, 10 * time.Second},
+	{"health.timeout", func(c *Config) *duration { return c.Health.Timeout }, time.Second},
+	{"leak_check.interval", func(c *Config) *duration { return c.LeakCheck.Interval }, 30 * time.Second},
+	{"transmission.stop_timeout", func(c *Config) *duration { return c.Transmission.StopTimeout }, time.Second},
+	{"notifications.interval", func(c *Config) *duration { return c.Notify.Interval }, 10 * time.Second},
+	{"ingest.interval", func(c *Config) *duration { return c.Ingest.Interval }, time.Minute},
+	{"ingest.timeout", func(c *Config) *duration { return c.Ingest.Timeout }, time.Second},
+}
+
+// Validate checks the values that would make transmon misbehave at runtime
+// rather than fail to start.
+func (c *Config) Validate() error {
+	for _, m := range minimums {
+		d := m.get(c)
+		if d == nil {
+			return fmt.Errorf("%s is not set", m.name)
+		}
+		if d.Duration < m.min {
+			return fmt.Errorf("%s must be at least %v, got %v", m.name, m.min, d.Duration)
+		}
+	}
+	for _, r := range c.Cleaner.Rules {
+		if er := r.Validate(); er != nil {
+			return er
+		}
+	}
+	switch c.VPN {
+	case OpenVPNTunnel:
+	case WireGuardTunnel:
+		wg := c.WireGuard
+		if wg == nil || wg.Config == "" {
+			return fmt.Errorf("vpn %q needs wireguard.config", c.VPN)
+		}
+		if wg.Mode != WGQuick && wg.Mode != WGSetconf {
+			return fmt.Errorf("wireguard.mode must be %s or %s, got %q", WGQuick, WGSetconf, wg.Mode)
+		}
+	default:
+		return fmt.Errorf("vpn must be %s or %s, got %q", OpenVPNTunnel, WireGuardTunnel, c.VPN)
+	}
+	if ng := c.PIA.NextGen; ng != nil && ng.CA == "" {
+		return fmt.Errorf("pia.next_gen needs a ca")
+	}
+	if o := c.PIA.OpenVPN; o != nil {
+		if o.Region == "" || o.CA == "" {
+			return fmt.Errorf("pia.openvpn needs a region and ca")
+		}
+		if o.Protocol != "udp" && o.Protocol != "tcp" {
+			return fmt.Errorf("pia.openvpn.protocol must be udp or tcp, got %q", o.Protocol)
+		}
+		if o.Cipher != pia.AES128 && o.Cipher != pia.AES256 {
+			return fmt.Errorf("pia.openvpn.cipher must be %s or %s, got %q", pia.AES128, pia.AES256, o.Cipher)
+		}
+	}
+	if c.Health.Failures < 1 {
+		return fmt.Errorf("health.failures must be at least 1, got %d", c.Health.Failures)
+	}
+	if c.Health.HomeIP != "" && net.ParseIP(c.Health.HomeIP) == nil {
+		return fmt.Errorf("health.home_ip %q is not an ip address", c.Health.HomeIP)
+	}
+	if a := c.LeakCheck.Action; a != LeakAlert && a != LeakRestart {
+		return fmt.Errorf("leak_check.action must be %s or %s, got %q", LeakAlert, LeakRestart, a)
+	}
+	if er := validateDesired(c.Transmission.Settings); er != nil {
+		return er
+	}
+	if c.Transmission.Backups < 1 {
+		return fmt.Errorf("transmission.settings_backups must be at least 1, got %d", c.Transmission.Backups)
+	}
+	if c.Failover.Attempts < 1 {
+		return fmt.Errorf("failover.attempts must be at least 1, got %d", c.Failover.Attempts)
+	}
+	if er := c.Notify.Validate(); er != nil {
+		return er
+	}
+	if er := c.Ingest.Validate(); er != nil {
+		return er
+	}
+	return c.Firewall.Validate()
+}
+
+func (f *Firewall) Validate() error {
+	if f.Backend != "iptables" && f.Backend != "nftables" {
+		return fmt.Errorf("firewall.backend must be iptables or nftables, got %q", f.Backend)
+	}
+	for _, n := range f.Local {
+		if _, _, er := net.ParseCIDR(n); er != nil {
+			return fmt.Errorf("firewall.local_networks: %v", er)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Section identifies a top level block of the config file. Sections are bit
+// flags so an Update can report several changed blocks at once.
+type Section uint
+
+const (
+	TimeoutSection Section = 1 << iota
+	IntervalsSection
+	CleanerSection
+	ForwardingSection
+	PIASection
+	TransmissionSection
+	OpenVPNSection
+	FirewallSection
+	HealthSection
+	LeakSection
+	VPNSection
+	FailoverSection
+	NotifySection
+	IngestSection
+)
+
+var sectionNames = []struct {
+	s    Section
+	name string
+}{
+	{TimeoutSection, "timeout"},
+	{IntervalsSection, "intervals"},
+	{CleanerSection, "cleaner"},
+	{ForwardingSection, "forwarding"},
+	{PIASection, "pia"},
+	{TransmissionSection, "transmission"},
+	{OpenVPNSection, "openvpn"},
+	{FirewallSection, "firewall"},
+	{HealthSection, "health"},
+	{LeakSection, "leak_check"},
+	{VPNSection, "vpn"},
+	{FailoverSection, "failover"},
+	{NotifySection, "notifications"},
+	{IngestSection, "ingest"},
+}
+
+func (s Section) String() string {
+	names := make([]string, 0, len(sectionNames))
+	for _, n := range sectionNames {
+		if s&n.s != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Update is handed to subscribers whenever a reload changes the config.
+type Update struct {
+	Old, New *Config
+	Changed  Section
+}
+
+func (u *Update) Has(s Section) bool {
+	return u.Changed&s != 0
+}
+
+// Watcher holds the current config snapshot. Readers always get a complete
+// *Config, reloads swap the whole snapshot atomically and never mutate one
+// that has already been handed out.
+type Watcher struct {
+	file   string
+	config atomic.Value
+	mu     sync.Mutex
+	subs   []chan *Update
+}
+
+func newWatcher(c *Config) *Watcher {
+	w := &Watcher{file: c.file}
+	w.config.Store(c)
+	return w
+}
+
+func (w *Watcher) Config() *Config {
+	return w.config.Load().(*Config)
+}
+
+// Subscribe returns a channel that receives an Update after every reload
+// that changed something. Slow subscribers never block the watcher, pending
+// updates are folded into the newest one instead.
+func (w *Watcher) Subscribe() <-chan *Update {
+	ch := make(chan *Update, 1)
+	w.mu.Lock()
+	w.subs = append(w.subs, ch)
+	w.mu.Unlock()
+	return ch
+}
+
+func (w *Watcher) reload() (*Update, error) {
+	old := w.Config()
+	info, er := os.Stat(w.file)
+	if er != nil {
+		return nil, er
+	}
+	if info.ModTime().Equal(old.modTime) {
+		return nil, nil
+	}
+
+	nc, er := read(w.file, info)
+	if er != nil {
+		return nil, er
+	}
+
+	changed := diff(old, nc)
+	w.config.Store(nc)
+	if changed == 0 {
+		logger.Debugf("Config file touched but nothing changed")
+		return nil, nil
+	}
+
+	u := &Update{Old: old, New: nc, Changed: changed}
+	w.notify(u)
+	return u, nil
+}
+
+func (w *Watcher) notify(u *Update) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, ch := range w.subs {
+		select {
+		case ch <- u:
+			continue
+		default:
+		}
+
+		pending := u
+		select {
+		case p := <-ch:
+			pending = &Update{Old: p.Old, New: u.New, Changed: p.Changed | u.Changed}
+		default:
+		}
+		ch <- pending
+	}
+}
+
+func diff(a, b *Config) Section {
+	var s Section
+	if !reflect.DeepEqual(a.Timeout, b.Timeout) {
+		s |= TimeoutSection
+	}
+	if !reflect.DeepEqual(a.Intervals, b.Intervals) {
+		s |= IntervalsSection
+	}
+	if !reflect.DeepEqual(a.Cleaner, b.Cleaner) {
+		s |= CleanerSection
+	}
+	if a.Provider != b.Provider || !reflect.DeepEqual(a.Static, b.Static) ||
+		!reflect.DeepEqual(a.PortCommand, b.PortCommand) || !reflect.DeepEqual(a.NATPMP, b.NATPMP) {
+		s |= ForwardingSection
+	}
+	if !reflect.DeepEqual(a.PIA, b.PIA) {
+		s |= PIASection
+	}
+	if !reflect.DeepEqual(a.Transmission, b.Transmission) {
+		s |= TransmissionSection
+	}
+	if !reflect.DeepEqual(a.OpenVPN, b.OpenVPN) {
+		s |= OpenVPNSection
+	}
+	if !reflect.DeepEqual(a.Firewall, b.Firewall) {
+		s |= FirewallSection
+	}
+	if !reflect.DeepEqual(a.Health, b.Health) {
+		s |= HealthSection
+	}
+	if !reflect.DeepEqual(a.LeakCheck, b.LeakCheck) {
+		s |= LeakSection
+	}
+	if a.VPN != b.VPN || !reflect.DeepEqual(a.WireGuard, b.WireGuard) {
+		s |= VPNSection
+	}
+	if !reflect.DeepEqual(a.Failover, b.Failover) {
+		s |= FailoverSection
+	}
+	if !reflect.DeepEqual(a.Notify, b.Notify) {
+		s |= NotifySection
+	}
+	if !reflect.DeepEqual(a.Ingest, b.Ingest) {
+		s |= IngestSection
+	}
+	return s
+}
@@ -0,0 +1,220 @@
+// Package failover picks the VPN server to connect to from an ordered list,
+// moving on when one keeps failing and preferring ones that recently worked.
+package failover
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/albertrdixon/transmon/atomicfile"
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/logging"
+	"github.com/albertrdixon/transmon/metrics"
+)
+
+var logger = logging.New("failover")
+
+// Server is how connecting to one server has gone.
+type Server struct {
+	Name        string    `json:"server"`
+	Successes   int       `json:"successes"`
+	Failures    int       `json:"failures"`
+	LastSuccess time.Time `json:"last_success"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+// working is true when the last attempt on s succeeded.
+func (s *Server) working() bool {
+	return !s.LastSuccess.IsZero() && s.LastSuccess.After(s.LastFailure)
+}
+
+func (s *Server) tried() bool {
+	return !s.LastSuccess.IsZero() || !s.LastFailure.IsZero()
+}
+
+// List is the servers of a failover section. A nil List has no servers and
+// leaves configs alone, so callers need not check for one.
+type List struct {
+	mu       sync.Mutex
+	servers  []*Server
+	current  *Server
+	failed   int
+	attempts int
+	file     string
+	now      func() time.Time
+}
+
+// New loads the server state from f.StateFile and starts on the best server.
+// It returns nil when f lists no servers.
+func New(f *config.Failover) *List {
+	if len(f.Servers) < 1 {
+		return nil
+	}
+	saved, er := load(f.StateFile)
+	if er != nil {
+		logger.Warnf("Ignoring failover state in %s: %v", f.StateFile, er)
+	}
+
+	l := &List{attempts: f.Attempts, file: f.StateFile, now: time.Now}
+	for _, name := range f.Servers {
+		s, ok := saved[name]
+		if !ok {
+			s = &Server{Name: name}
+		}
+		l.servers = append(l.servers, s)
+	}
+	l.current = l.best(nil)
+	return l
+}
+
+// Apply points c at the current server.
+func (l *List) Apply(c *config.Config) *config.Config {
+	if l == nil {
+		return c
+	}
+	return c.WithServer(l.Current())
+}
+
+// Current is the name of the server in use.
+func (l *List) Current() string {
+	if l == nil {
+		return ""
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.current.Name
+}
+
+// Tries is how many connections it takes to give every server its attempts,
+// 1 without servers.
+func (l *List) Tries() int {
+	if l == nil {
+		return 1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.attempts * len(l.servers)
+}
+
+// Servers is a snapshot of every server in config order.
+func (l *List) Servers() []Server {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]Server, len(l.servers))
+	for i, s := range l.servers {
+		out[i] = *s
+	}
+	return out
+}
+
+// Report records the result of connecting to the current server and
+// forwarding a port through it. After attempts failures in a row it moves
+// to the best other server and returns true.
+func (l *List) Report(er error) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	defer l.save()
+
+	s := l.current
+	if er == nil {
+		s.Successes++
+		s.LastSuccess = l.now()
+		l.failed = 0
+		return false
+	}
+	s.Failures++
+	s.LastFailure = l.now()
+	l.failed++
+	if l.failed < l.attempts || len(l.servers) < 2 {
+		return false
+	}
+
+	l.current, l.failed = l.best(s), 0
+	metrics.Failovers.Inc()
+	logger.Warnf("Failing over from %s to %s after %d failed attempts", s.Name, l.current.Name, l.attempts)
+	return true
+}
+
+// best ranks servers that worked last time first, most recent first, then
+// untried ones and then failing ones, least recently failed first. Ties
+// keep config order.
+func (l *List) best(skip *Server) *Server {
+	ranked := make([]*Server, 0, len(l.servers))
+	for _, s := range l.servers {
+		if s != skip {
+			ranked = append(ranked, s)
+		}
+	}
+	sort.Stable(byHealth(ranked))
+	return ranked[0]
+}
+
+type byHealth []*Server
+
+func (b byHealth) Len() int      { return len(b) }
+func (b byHealth) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byHealth) Less(i, j int) bool {
+	ri, rj := rank(b[i]), rank(b[j])
+	switch {
+	case ri != rj:
+		return ri < rj
+	case ri == 0:
+		return b[i].LastSuccess.After(b[j].LastSuccess)
+	case ri == 2:
+		return b[i].LastFailure.Before(b[j].LastFailure)
+	}
+	return false
+}
+
+func rank(s *Server) int {
+	switch {
+	case s.working():
+		return 0
+	case !s.tried():
+		return 1
+	}
+	return 2
+}
+
+// load reads server state keyed by name. A missing file is an empty state.
+func load(file string) (map[string]*Server, error) {
+	state := make(map[string]*Server)
+	data, er := ioutil.ReadFile(file)
+	if os.IsNotExist(er) {
+		return state, nil
+	} else if er != nil {
+		return state, er
+	}
+
+	var list []*Server
+	if er := json.Unmarshal(data, &list); er != nil {
+		return state, er
+	}
+	for _, s := range list {
+		state[s.Name] = s
+	}
+	return state, nil
+}
+
+func (l *List) save() {
+	if l.file == "" {
+		return
+	}
+	data, er := json.Marshal(l.servers)
+	if er == nil {
+		er = atomicfile.Write(l.file, data)
+	}
+	if er != nil {
+		logger.Warnf("Failed to save failover state to %s: %v", l.file, er)
+	}
+}
@@ -0,0 +1,64 @@
+package failover
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "failover")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		f    = &config.Failover{Servers: []string{"nl", "swiss", "sweden"}, Attempts: 2, StateFile: filepath.Join(dir, "failover.json")}
+		now  = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+		fail = errors.New("no port")
+	)
+	is.Nil(New(&config.Failover{}))
+	is.Equal("", (*List)(nil).Current())
+	is.False((*List)(nil).Report(fail))
+	is.Equal(1, (*List)(nil).Tries())
+
+	l := New(f)
+	l.now = func() time.Time { now = now.Add(time.Minute); return now }
+	is.Equal("nl", l.Current())
+	is.Equal(6, l.Tries())
+
+	is.False(l.Report(fail))
+	is.True(l.Report(fail), "second failure in a row moves on")
+	is.Equal("swiss", l.Current())
+	is.False(l.Report(nil))
+
+	is.False(l.Report(fail))
+	is.True(l.Report(fail))
+	is.Equal("sweden", l.Current(), "untried servers come before failing ones")
+	is.False(l.Report(fail))
+	is.True(l.Report(fail))
+	is.Equal("nl", l.Current(), "the server that failed longest ago is retried first")
+
+	is.False(l.Report(nil))
+	l = New(f)
+	is.Equal("nl", l.Current(), "state is saved and the last working server preferred")
+	servers := l.Servers()
+	if is.Len(servers, 3) {
+		is.Equal(1, servers[0].Successes)
+		is.Equal(2, servers[0].Failures)
+		is.Equal(1, servers[1].Successes)
+		is.Equal(2, servers[2].Failures)
+	}
+
+	one := New(&config.Failover{Servers: []string{"nl"}, Attempts: 1})
+	is.False(one.Report(fail), "nowhere to go with one server")
+	is.Equal("nl", one.Current())
+}
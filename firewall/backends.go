@@ -0,0 +1,110 @@
+package firewall
+
+import "strconv"
+
+type iptables struct{}
+
+func (iptables) Name() string { return IPTables }
+
+// Up hooks a TRANSMON chain into OUTPUT. Over IPv6 Transmission only gets
+// loopback since the tunnel is IPv4.
+func (iptables) Up(r *Rules) [][]string {
+	var (
+		uid  = strconv.Itoa(r.UID)
+		self = strconv.Itoa(r.Self)
+		v4   = func(args ...string) []string { return append([]string{"iptables"}, args...) }
+		v6   = func(args ...string) []string { return append([]string{"ip6tables"}, args...) }
+	)
+
+	cmds := [][]string{
+		v4("-N", chain),
+		v4("-A", chain, "-o", "lo", "-j", "ACCEPT"),
+		v4("-A", chain, "-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"),
+	}
+	for _, n := range r.Local {
+		cmds = append(cmds, v4("-A", chain, "-d", n.String(), "-j", "ACCEPT"))
+	}
+	for _, e := range r.Endpoints {
+		rule := []string{"-A", chain, "-d", e.IP.String()}
+		if e.Port > 0 {
+			rule = append(rule, "-p", e.Proto, "--dport", strconv.Itoa(e.Port))
+		}
+		cmds = append(cmds, v4(append(rule, "-j", "ACCEPT")...))
+	}
+	for _, tun := range r.Tun {
+		cmds = append(cmds, v4("-A", chain, "-o", tun, "-m", "owner", "--uid-owner", uid, "-j", "ACCEPT"))
+		if r.Self != r.UID {
+			cmds = append(cmds, v4("-A", chain, "-o", tun, "-m", "owner", "--uid-owner", self, "-j", "ACCEPT"))
+		}
+	}
+	for _, tun := range r.Tun {
+		cmds = append(cmds, v4("-A", chain, "-o", tun, "-j", "REJECT"))
+	}
+	return append(cmds,
+		v4("-A", chain, "-m", "owner", "--uid-owner", uid, "-j", "REJECT"),
+		v4("-I", "OUTPUT", "-j", chain),
+		v6("-N", chain),
+		v6("-A", chain, "-o", "lo", "-j", "ACCEPT"),
+		v6("-A", chain, "-m", "owner", "--uid-owner", uid, "-j", "REJECT"),
+		v6("-I", "OUTPUT", "-j", chain),
+	)
+}
+
+func (iptables) Down() [][]string {
+	var cmds [][]string
+	for _, bin := range []string{"iptables", "ip6tables"} {
+		cmds = append(cmds,
+			[]string{bin, "-D", "OUTPUT", "-j", chain},
+			[]string{bin, "-F", chain},
+			[]string{bin, "-X", chain},
+		)
+	}
+	return cmds
+}
+
+type nftables struct{}
+
+func (nftables) Name() string { return NFTables }
+
+// Up adds an inet transmon table with one output chain covering IPv4 and
+// IPv6 alike.
+func (nftables) Up(r *Rules) [][]string {
+	var (
+		uid  = strconv.Itoa(r.UID)
+		self = strconv.Itoa(r.Self)
+		rule = func(args ...string) []string {
+			return append([]string{"nft", "add", "rule", "inet", table, "output"}, args...)
+		}
+	)
+
+	cmds := [][]string{
+		{"nft", "add", "table", "inet", table},
+		{"nft", "add", "chain", "inet", table, "output", "{", "type", "filter", "hook", "output", "priority", "0", ";", "policy", "accept", ";", "}"},
+		rule("oifname", "lo", "accept"),
+		rule("ct", "state", "established,related", "accept"),
+	}
+	for _, n := range r.Local {
+		cmds = append(cmds, rule("ip", "daddr", n.String(), "accept"))
+	}
+	for _, e := range r.Endpoints {
+		if e.Port > 0 {
+			cmds = append(cmds, rule("ip", "daddr", e.IP.String(), e.Proto, "dport", strconv.Itoa(e.Port), "accept"))
+		} else {
+			cmds = append(cmds, rule("ip", "daddr", e.IP.String(), "accept"))
+		}
+	}
+	for _, tun := range r.Tun {
+		cmds = append(cmds, rule("oifname", tun, "meta", "skuid", uid, "accept"))
+		if r.Self != r.UID {
+			cmds = append(cmds, rule("oifname", tun, "meta", "skuid", self, "accept"))
+		}
+	}
+	for _, tun := range r.Tun {
+		cmds = append(cmds, rule("oifname", tun, "reject"))
+	}
+	return append(cmds, rule("meta", "skuid", uid, "reject"))
+}
+
+func (nftables) Down() [][]string {
+	return [][]string{{"nft", "delete", "table", "inet", table}}
+}
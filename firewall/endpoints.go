@@ -0,0 +1,229 @@
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/vpn"
+)
+
+// Endpoint is a VPN server the tunnel connects to. Port 0 allows the whole
+// host.
+type Endpoint struct {
+	Host  string
+	IP    net.IP
+	Port  int
+	Proto string
+}
+
+func (e *Endpoint) String() string {
+	host := e.Host
+	if e.IP != nil {
+		host = e.IP.String()
+	}
+	if e.Port < 1 {
+		return host
+	}
+	return fmt.Sprintf("%s:%d/%s", host, e.Port, e.Proto)
+}
+
+// ParseEndpoint reads host[:port][/proto], the protocol defaults to udp.
+func ParseEndpoint(s string) (*Endpoint, error) {
+	e := &Endpoint{Host: s, Proto: "udp"}
+	if i := strings.LastIndex(e.Host, "/"); i > -1 {
+		e.Host, e.Proto = e.Host[:i], proto(e.Host[i+1:])
+	}
+	if host, port, er := net.SplitHostPort(e.Host); er == nil {
+		p, er := strconv.Atoi(port)
+		if er != nil || p < 1 || p > 65535 {
+			return nil, fmt.Errorf("Bad port in endpoint %q", s)
+		}
+		e.Host, e.Port = host, p
+	}
+	if e.Host == "" {
+		return nil, fmt.Errorf("Bad endpoint %q", s)
+	}
+	if e.Proto != "udp" && e.Proto != "tcp" {
+		return nil, fmt.Errorf("Bad protocol in endpoint %q", s)
+	}
+	return e, nil
+}
+
+func tunnelEndpoints(c *config.Config) ([]*Endpoint, error) {
+	if c.VPN != config.WireGuardTunnel {
+		eps, er := Endpoints(c.OpenVPN.Command)
+		if er != nil || c.PIA.OpenVPN == nil {
+			return eps, er
+		}
+		o, er := vpn.PIAOpenVPN(c)
+		if er != nil {
+			return nil, er
+		}
+		port, er := o.Port()
+		if er != nil {
+			return nil, er
+		}
+		return append(eps, &Endpoint{Host: o.Region.Host, Port: port, Proto: o.Protocol}), nil
+	}
+	wg, er := vpn.ReadWireGuard(c.WireGuard.Config)
+	if er != nil {
+		return nil, er
+	}
+	eps := make([]*Endpoint, 0, len(wg.Endpoints))
+	for _, e := range wg.Endpoints {
+		ep, er := ParseEndpoint(e + "/udp")
+		if er != nil {
+			return nil, er
+		}
+		eps = append(eps, ep)
+	}
+	return eps, nil
+}
+
+// Endpoints finds the servers an openvpn command connects to, from its
+// --remote options and the remote lines of its --config file.
+func Endpoints(command string) ([]*Endpoint, error) {
+	var (
+		o    = &options{port: 1194, proto: "udp"}
+		args = strings.Fields(command)
+		dir  string
+		conf []string
+	)
+	if len(args) == 2 && !strings.HasPrefix(args[1], "--") {
+		conf = append(conf, args[1])
+	}
+	for i := 1; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") {
+			continue
+		}
+		var params []string
+		for j := i + 1; j < len(args) && !strings.HasPrefix(args[j], "--"); j++ {
+			params = append(params, args[j])
+		}
+		switch args[i] {
+		case "--cd":
+			if len(params) > 0 {
+				dir = params[0]
+			}
+		case "--config":
+			if len(params) > 0 {
+				conf = append(conf, params[0])
+			}
+		default:
+			o.set(strings.TrimPrefix(args[i], "--"), params)
+		}
+	}
+
+	for _, file := range conf {
+		if !filepath.IsAbs(file) && dir != "" {
+			file = filepath.Join(dir, file)
+		}
+		if er := o.read(file); er != nil {
+			return nil, er
+		}
+	}
+	return o.endpoints(), nil
+}
+
+type options struct {
+	port    int
+	proto   string
+	remotes [][]string
+}
+
+func (o *options) set(name string, params []string) {
+	switch name {
+	case "remote":
+		if len(params) > 0 {
+			o.remotes = append(o.remotes, params)
+		}
+	case "proto":
+		if len(params) > 0 {
+			o.proto = proto(params[0])
+		}
+	case "port", "rport":
+		if len(params) > 0 {
+			if p, er := strconv.Atoi(params[0]); er == nil {
+				o.port = p
+			}
+		}
+	}
+}
+
+func (o *options) read(file string) error {
+	f, er := os.Open(file)
+	if er != nil {
+		return er
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		fields := strings.Fields(line)
+		o.set(fields[0], fields[1:])
+	}
+	return s.Err()
+}
+
+func (o *options) endpoints() []*Endpoint {
+	eps := make([]*Endpoint, 0, len(o.remotes))
+	for _, r := range o.remotes {
+		e := &Endpoint{Host: r[0], Port: o.port, Proto: o.proto}
+		if len(r) > 1 {
+			if p, er := strconv.Atoi(r[1]); er == nil {
+				e.Port = p
+			}
+		}
+		if len(r) > 2 {
+			e.Proto = proto(r[2])
+		}
+		eps = append(eps, e)
+	}
+	return eps
+}
+
+// proto maps openvpn protocol names like tcp-client or udp4 to tcp or udp.
+func proto(p string) string {
+	p = strings.ToLower(p)
+	switch {
+	case strings.HasPrefix(p, "tcp"):
+		return "tcp"
+	case strings.HasPrefix(p, "udp"):
+		return "udp"
+	}
+	return p
+}
+
+// resolve looks up the IPv4 addresses of each endpoint. Rules are installed
+// by address so this has to happen before the tunnel is up.
+func resolve(eps []*Endpoint) ([]*Endpoint, error) {
+	var out []*Endpoint
+	for _, e := range eps {
+		ips := []net.IP{net.ParseIP(e.Host)}
+		if ips[0] == nil {
+			var er error
+			if ips, er = net.LookupIP(e.Host); er != nil {
+				return nil, er
+			}
+		}
+		for _, ip := range ips {
+			if ip4 := ip.To4(); ip4 != nil {
+				out = append(out, &Endpoint{Host: e.Host, IP: ip4, Port: e.Port, Proto: e.Proto})
+			}
+		}
+	}
+	if len(out) < 1 {
+		return nil, fmt.Errorf("No IPv4 addresses for VPN endpoints")
+	}
+	return out, nil
+}
@@ -0,0 +1,207 @@
+// Package firewall installs the kill switch: egress rules that only let
+// Transmission reach the network through the VPN tunnel.
+package firewall
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/logging"
+)
+
+var logger = logging.New("firewall")
+
+const (
+	IPTables = "iptables"
+	NFTables = "nftables"
+
+	chain = "TRANSMON"
+	table = "transmon"
+)
+
+// Rules is what the kill switch lets through. Everything else Transmission
+// sends is rejected, as is anything another user sends through the tunnel.
+type Rules struct {
+	Tun       []string
+	UID       int
+	Self      int
+	Local     []*net.IPNet
+	Endpoints []*Endpoint
+}
+
+// Backend turns Rules into the commands that install and remove them.
+type Backend interface {
+	Name() string
+	Up(r *Rules) [][]string
+	Down() [][]string
+}
+
+type Firewall struct {
+	Backend
+	Rules *Rules
+	run   func(args []string) error
+}
+
+// New builds the kill switch for c, resolving the VPN endpoints from the
+// openvpn command and firewall.endpoints. With failover servers the tunnel
+// devices and endpoints of every server are let through.
+func New(c *config.Config) (*Firewall, error) {
+	f := c.Firewall
+	r := &Rules{UID: c.Transmission.UID, Self: os.Getuid()}
+	for _, n := range f.Local {
+		_, network, er := net.ParseCIDR(n)
+		if er != nil {
+			return nil, er
+		}
+		r.Local = append(r.Local, network)
+	}
+
+	var eps []*Endpoint
+	for _, sc := range servers(c) {
+		r.addTun(sc.Device())
+		e, er := tunnelEndpoints(sc)
+		if er != nil {
+			return nil, er
+		}
+		eps = append(eps, e...)
+	}
+	for _, e := range f.Endpoints {
+		ep, er := ParseEndpoint(e)
+		if er != nil {
+			return nil, er
+		}
+		eps = append(eps, ep)
+	}
+	if len(eps) < 1 {
+		return nil, fmt.Errorf("No %s endpoints found, set firewall.endpoints", c.VPN)
+	}
+	var er error
+	if r.Endpoints, er = resolve(eps); er != nil {
+		return nil, er
+	}
+
+	if r.UID == r.Self {
+		logger.Warnf("Transmission runs as uid %d like transmon, the kill switch applies to both", r.UID)
+	}
+
+	b, er := backend(f.Backend)
+	if er != nil {
+		return nil, er
+	}
+	return &Firewall{Backend: b, Rules: r, run: execute}, nil
+}
+
+// Remove takes down whatever rules c's backend left installed without
+// resolving any endpoints.
+func Remove(c *config.Config) error {
+	b, er := backend(c.Firewall.Backend)
+	if er != nil {
+		return er
+	}
+	return (&Firewall{Backend: b, run: execute}).Down()
+}
+
+// servers is c once for each failover server, or just c.
+func servers(c *config.Config) []*config.Config {
+	if len(c.Failover.Servers) < 1 {
+		return []*config.Config{c}
+	}
+	out := make([]*config.Config, 0, len(c.Failover.Servers))
+	for _, s := range c.Failover.Servers {
+		out = append(out, c.WithServer(s))
+	}
+	return out
+}
+
+func (r *Rules) addTun(dev string) {
+	for _, t := range r.Tun {
+		if t == dev {
+			return
+		}
+	}
+	r.Tun = append(r.Tun, dev)
+}
+
+func backend(name string) (Backend, error) {
+	switch name {
+	case IPTables:
+		return iptables{}, nil
+	case NFTables:
+		return nftables{}, nil
+	}
+	return nil, fmt.Errorf("Unknown firewall backend %q", name)
+}
+
+// Up replaces any rules left from an earlier run with the current ones.
+func (f *Firewall) Up() error {
+	f.down()
+	logger.Infof("Installing %s kill switch for uid %d on %s", f.Name(), f.Rules.UID, strings.Join(f.Rules.Tun, ", "))
+	for _, cmd := range f.Backend.Up(f.Rules) {
+		if er := f.run(cmd); er != nil {
+			f.down()
+			return er
+		}
+	}
+	return nil
+}
+
+// Down removes the rules. Transmission is no longer held to the tunnel
+// afterwards.
+func (f *Firewall) Down() error {
+	logger.Infof("Removing %s kill switch", f.Name())
+	return f.down()
+}
+
+func (f *Firewall) down() error {
+	var last error
+	for _, cmd := range f.Backend.Down() {
+		if er := f.run(cmd); er != nil {
+			logger.Debugf("%v", er)
+			last = er
+		}
+	}
+	return last
+}
+
+// Script writes a shell script that does what Up does.
+func (f *Firewall) Script(w io.Writer) error {
+	lines := []string{"#!/bin/sh", "# transmon kill switch (" + f.Name() + ")"}
+	for _, cmd := range f.Backend.Down() {
+		lines = append(lines, join(cmd)+" 2>/dev/null || true")
+	}
+	lines = append(lines, "set -e")
+	for _, cmd := range f.Backend.Up(f.Rules) {
+		lines = append(lines, join(cmd))
+	}
+	_, er := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return er
+}
+
+func execute(args []string) error {
+	logger.Debugf("Running %s", join(args))
+	out, er := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if er != nil {
+		return fmt.Errorf("%s: %v: %s", join(args), er, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+var safe = regexp.MustCompile(`^[A-Za-z0-9_./:,=+@%-]+$`)
+
+func join(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if safe.MatchString(a) {
+			quoted[i] = a
+		} else {
+			quoted[i] = "'" + strings.Replace(a, "'", `'\''`, -1) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,106 @@
+package firewall
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testRules() *Rules {
+	_, local, _ := net.ParseCIDR("192.168.1.0/24")
+	return &Rules{
+		Tun:       []string{"tun0"},
+		UID:       1000,
+		Self:      0,
+		Local:     []*net.IPNet{local},
+		Endpoints: []*Endpoint{{IP: net.ParseIP("1.2.3.4").To4(), Port: 1198, Proto: "udp"}},
+	}
+}
+
+func TestIPTables(t *testing.T) {
+	is := assert.New(t)
+	var (
+		ran []string
+		f   = &Firewall{Backend: iptables{}, Rules: testRules(), run: func(args []string) error {
+			ran = append(ran, join(args))
+			return nil
+		}}
+	)
+
+	is.NoError(f.Up())
+	is.Contains(ran, "iptables -A TRANSMON -d 192.168.1.0/24 -j ACCEPT")
+	is.Contains(ran, "iptables -A TRANSMON -d 1.2.3.4 -p udp --dport 1198 -j ACCEPT")
+	is.Contains(ran, "iptables -A TRANSMON -o tun0 -m owner --uid-owner 1000 -j ACCEPT")
+	is.Contains(ran, "iptables -A TRANSMON -o tun0 -m owner --uid-owner 0 -j ACCEPT")
+	is.Contains(ran, "ip6tables -A TRANSMON -m owner --uid-owner 1000 -j REJECT")
+	is.Equal("iptables -D OUTPUT -j TRANSMON", ran[0])
+
+	accept, reject := index(ran, "--uid-owner 1000 -j ACCEPT"), index(ran, "iptables -A TRANSMON -m owner --uid-owner 1000 -j REJECT")
+	is.True(accept > -1 && accept < reject)
+}
+
+func TestNFTablesScript(t *testing.T) {
+	is := assert.New(t)
+	var (
+		b bytes.Buffer
+		f = &Firewall{Backend: nftables{}, Rules: testRules()}
+	)
+
+	is.NoError(f.Script(&b))
+	s := b.String()
+	is.True(strings.HasPrefix(s, "#!/bin/sh\n"))
+	is.Contains(s, "nft delete table inet transmon 2>/dev/null || true\nset -e\n")
+	is.Contains(s, "nft add chain inet transmon output '{' type filter hook output priority 0 ';' policy accept ';' '}'\n")
+	is.Contains(s, "nft add rule inet transmon output ip daddr 1.2.3.4 udp dport 1198 accept\n")
+	is.Contains(s, "nft add rule inet transmon output meta skuid 1000 reject\n")
+}
+
+func TestEndpoints(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := "client\nproto tcp-client\n# remote 9.9.9.9\nremote 1.2.3.4 443\nremote 5.6.7.8 1198 udp\n"
+	if er := ioutil.WriteFile(filepath.Join(dir, "vpn.ovpn"), []byte(conf), 0600); er != nil {
+		t.Fatal(er)
+	}
+
+	eps, er := Endpoints("openvpn --cd " + dir + " --config vpn.ovpn --remote 10.0.0.1 --daemon")
+	is.NoError(er)
+	if is.Len(eps, 3) {
+		is.Equal("10.0.0.1:1194/tcp", eps[0].String())
+		is.Equal("1.2.3.4:443/tcp", eps[1].String())
+		is.Equal("5.6.7.8:1198/udp", eps[2].String())
+	}
+
+	eps, er = Endpoints("openvpn --cd /openvpn --daemon my-ovpn")
+	is.NoError(er)
+	is.Empty(eps)
+
+	e, er := ParseEndpoint("vpn.example.com:1198/udp")
+	is.NoError(er)
+	is.Equal("vpn.example.com:1198/udp", e.String())
+	e, er = ParseEndpoint("1.2.3.4")
+	is.NoError(er)
+	is.Equal(0, e.Port)
+	_, er = ParseEndpoint("1.2.3.4:1198/icmp")
+	is.Error(er)
+}
+
+func index(list []string, substr string) int {
+	for i, s := range list {
+		if strings.Contains(s, substr) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,63 @@
+package forward
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/albertrdixon/transmon/logging"
+)
+
+var logger = logging.New("forward")
+
+// CommandPort runs a shell command and reads the forwarded port from its
+// output. The tunnel address is passed in the TRANSMON_IP environment var.
+type CommandPort struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (c *CommandPort) Name() string {
+	return Command
+}
+
+func (c *CommandPort) RequestPort(ip string) (int, error) {
+	logger.Debugf("Running port command: %q", c.Command)
+	var out, errOut bytes.Buffer
+	cmd := exec.Command("/bin/sh", "-c", c.Command)
+	cmd.Env = append(os.Environ(), "TRANSMON_IP="+ip)
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+
+	if er := cmd.Start(); er != nil {
+		return 0, er
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var timeout <-chan time.Time
+	if c.Timeout > 0 {
+		timeout = time.After(c.Timeout)
+	}
+	select {
+	case er := <-done:
+		if er != nil {
+			return 0, fmt.Errorf("Port command failed: %v: %s", er, strings.TrimSpace(errOut.String()))
+		}
+	case <-timeout:
+		cmd.Process.Kill()
+		return 0, fmt.Errorf("Port command timed out after %v", c.Timeout)
+	}
+
+	port, er := strconv.Atoi(strings.TrimSpace(out.String()))
+	if er != nil {
+		return 0, fmt.Errorf("Port command returned bad port: %v", er)
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("Port command returned out of range port %d", port)
+	}
+	return port, nil
+}
@@ -0,0 +1,65 @@
+package forward
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/natpmp"
+	"github.com/albertrdixon/transmon/pia"
+)
+
+// PortForwarder gets a port forwarded to the tunnel address ip from
+// whichever service the VPN provider offers.
+type PortForwarder interface {
+	Name() string
+	RequestPort(ip string) (int, error)
+}
+
+// Renewer is implemented by forwarders whose port mapping runs out unless
+// it is refreshed every RenewInterval. Renew may hand back a different port.
+type Renewer interface {
+	Renew(ip string) (int, error)
+	RenewInterval() time.Duration
+}
+
+const (
+	PIA        = "pia"
+	PIANextGen = "pia-nextgen"
+	NATPMP     = natpmp.Name
+	Static     = "static"
+	Command    = "command"
+)
+
+// New returns the forwarder named by c.Provider.
+func New(c *config.Config) (PortForwarder, error) {
+	switch c.Provider {
+	case PIA:
+		return &pia.Legacy{User: c.PIA.User, Pass: c.PIA.Pass, ClientID: c.PIA.ClientID}, nil
+	case PIANextGen:
+		ng := c.PIA.NextGen
+		if ng == nil {
+			return nil, fmt.Errorf("Provider %q needs a pia.next_gen section", c.Provider)
+		}
+		n, er := pia.NewNextGen(c.PIA.User, c.PIA.Pass, ng.Hostname, ng.Gateway, ng.CA, ng.StateFile)
+		if er != nil {
+			return nil, er
+		}
+		n.TokenURL = ng.TokenURL
+		return n, nil
+	case NATPMP:
+		n := c.NATPMP
+		return natpmp.NewForwarder(n.Gateway, n.InternalPort, n.Lifetime.Duration, c.Timeout.Duration), nil
+	case Static:
+		if c.Static == nil || c.Static.Port < 1 {
+			return nil, fmt.Errorf("Provider %q needs static.port", c.Provider)
+		}
+		return &StaticPort{Port: c.Static.Port}, nil
+	case Command:
+		if c.PortCommand == nil || c.PortCommand.Command == "" {
+			return nil, fmt.Errorf("Provider %q needs port_command.command", c.Provider)
+		}
+		return &CommandPort{Command: c.PortCommand.Command, Timeout: c.Timeout.Duration}, nil
+	}
+	return nil, fmt.Errorf("Unknown port forwarding provider %q", c.Provider)
+}
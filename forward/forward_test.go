@@ -0,0 +1,59 @@
+package forward
+
+import (
+	"testing"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	is := assert.New(t)
+	c, er := config.Read("../config/examples/config.yml")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+
+	pf, er := New(c)
+	is.NoError(er)
+	is.Equal(PIA, pf.Name())
+
+	c.Provider = Static
+	_, er = New(c)
+	is.Error(er)
+	c.Static = &config.StaticPort{Port: 51413}
+	pf, er = New(c)
+	is.NoError(er)
+	port, er := pf.RequestPort("10.0.0.2")
+	is.NoError(er)
+	is.Equal(51413, port)
+
+	c.Provider = NATPMP
+	pf, er = New(c)
+	is.NoError(er)
+	_, ok := pf.(Renewer)
+	is.True(ok)
+
+	c.Provider = "nope"
+	_, er = New(c)
+	is.Error(er)
+}
+
+func TestCommandPort(t *testing.T) {
+	is := assert.New(t)
+
+	c := &CommandPort{Command: `echo "  $((40000 + ${TRANSMON_IP##*.}))"`, Timeout: time.Second}
+	port, er := c.RequestPort("10.0.0.2")
+	is.NoError(er)
+	is.Equal(40002, port)
+
+	c.Command = "echo nope"
+	_, er = c.RequestPort("10.0.0.2")
+	is.Error(er)
+
+	c.Command = "sleep 5"
+	c.Timeout = 50 * time.Millisecond
+	_, er = c.RequestPort("10.0.0.2")
+	is.Error(er)
+}
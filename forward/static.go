@@ -0,0 +1,15 @@
+package forward
+
+// StaticPort is for providers that forward a fixed port configured in their
+// control panel.
+type StaticPort struct {
+	Port int
+}
+
+func (s *StaticPort) Name() string {
+	return Static
+}
+
+func (s *StaticPort) RequestPort(ip string) (int, error) {
+	return s.Port, nil
+}
@@ -1,71 +1,104 @@
 package main
 
 import (
+	"fmt"
+	"reflect"
 	"time"
 
-	"github.com/albertrdixon/gearbox/logger"
-	"github.com/albertrdixon/gearbox/process"
 	"github.com/albertrdixon/transmon/config"
-	"github.com/albertrdixon/transmon/pia"
+	"github.com/albertrdixon/transmon/failover"
+	"github.com/albertrdixon/transmon/firewall"
+	"github.com/albertrdixon/transmon/forward"
+	"github.com/albertrdixon/transmon/logging"
+	"github.com/albertrdixon/transmon/metrics"
 	"github.com/albertrdixon/transmon/transmission"
 	"github.com/albertrdixon/transmon/vpn"
 	"github.com/cenkalti/backoff"
 	"golang.org/x/net/context"
 )
 
-func portCheck(t *process.Process, c *config.Config, ctx context.Context) error {
-	if transmission.
-		NewRawClient(c.Transmission.URL.String(), c.Transmission.User, c.Transmission.Pass).
-		CheckPort() {
+func portCheck(p *processes, pf forward.PortForwarder, c *config.Config, ctx context.Context) error {
+	open := rpcClient(c).CheckPort()
+	state.setPortOpen(open)
+	metrics.PortOpen.With().SetBool(open)
+	if open {
+		metrics.PortChecks.With("open").Inc()
+	} else {
+		metrics.PortChecks.With("closed").Inc()
+	}
+	if open {
 		return nil
 	}
 
-	logger.Infof("Transmission port not open, stopping transmission")
-	t.Stop()
+	logger.Infof("Transmission port not open, rebinding transmission")
+	return rebind(p, pf, c, ctx)
+}
 
-	ip, er := getIP(c.OpenVPN.Tun, c.Timeout.Duration, ctx)
-	if er != nil {
+func portUpdate(pf forward.PortForwarder, c *config.Config, ctx context.Context) error {
+	ip, er := getIP(c.Device(), c.Timeout.Duration, ctx)
+	if ctx.Err() != nil {
+		return er
+	} else if er != nil {
+		countPortUpdate(er)
 		return er
 	}
-	logger.Infof("New bind ip: (%s) %s", c.OpenVPN.Tun, ip)
+	logger.Event("bind_ip", logging.Fields{"ip": ip, "device": c.Device()}).Infof("New bind ip: (%s) %s", c.Device(), ip)
+	return bindPort(ip, pf, c, ctx)
+}
 
-	port, er := getPort(ip, c.PIA.User, c.PIA.Pass, c.PIA.ClientID, c.Timeout.Duration, ctx)
-	if er != nil {
+// bindPort forwards a port to ip and hands it to the running Transmission.
+func bindPort(ip string, pf forward.PortForwarder, c *config.Config, ctx context.Context) (er error) {
+	defer func() {
+		if ctx.Err() == nil {
+			countPortUpdate(er)
+		}
+	}()
+	port, er := getPort(ip, pf, c.Timeout.Duration, ctx)
+	if er != nil || ctx.Err() != nil {
 		return er
 	}
-	logger.Infof("New peer port: %d", port)
 
-	if er := transmission.UpdateSettings(c.Transmission.Config, ip, port); er != nil {
+	logger.Event("peer_port", logging.Fields{"port": port, "ip": ip, "provider": pf.Name()}).Infof("New peer port: %d", port)
+	if er := setPort(port, c, ctx); er != nil {
 		return er
 	}
-
-	logger.Infof("Starting transmission")
-	go t.ExecuteAndRestart(ctx)
+	state.setBinding(ip, port)
 	return nil
 }
 
-func portUpdate(c *config.Config, ctx context.Context) error {
-	ip, er := getIP(c.OpenVPN.Tun, c.Timeout.Duration, ctx)
+// portRenew refreshes the mapping of a Renewer and only touches Transmission
+// when the forwarded port is not the one it already has.
+func portRenew(r forward.Renewer, c *config.Config, ctx context.Context) error {
+	ip, er := getIP(c.Device(), c.Timeout.Duration, ctx)
 	if er != nil || ctx.Err() != nil {
 		return er
 	}
-	logger.Infof("New bind ip: (%s) %s", c.OpenVPN.Tun, ip)
 
-	port, er := getPort(ip, c.PIA.User, c.PIA.Pass, c.PIA.ClientID, c.Timeout.Duration, ctx)
-	if er != nil || ctx.Err() != nil {
+	current := state.Port()
+	port, er := r.Renew(ip)
+	if er != nil || port == current {
 		return er
 	}
 
-	logger.Infof("New peer port: %d", port)
+	logger.Event("forwarded_port_changed", logging.Fields{"port": port, "old_port": current, "ip": ip}).
+		Infof("Forwarded port changed: %d -> %d", current, port)
+	er = setPort(port, c, ctx)
+	countPortUpdate(er)
+	if er != nil {
+		return er
+	}
+	state.setBinding(ip, port)
+	return nil
+}
+
+func setPort(port int, c *config.Config, ctx context.Context) error {
 	notify := func(e error, w time.Duration) {
-		logger.Debugf("Failed to update transmission port: %v", er)
+		logger.Debugf("Failed to update transmission port: %v", e)
 	}
 	operation := func() error {
 		select {
 		default:
-			return transmission.
-				NewRawClient(c.Transmission.URL.String(), c.Transmission.User, c.Transmission.Pass).
-				UpdatePort(port)
+			return rpcClient(c).UpdatePort(port)
 		case <-ctx.Done():
 			return nil
 		}
@@ -75,60 +108,238 @@ func portUpdate(c *config.Config, ctx context.Context) error {
 	return backoff.RetryNotify(operation, b, notify)
 }
 
-func restartProcesses(t, v *process.Process, c *config.Config, ctx context.Context) error {
+// restartProcesses reconnects until it works or 30 minutes pass, moving to
+// the next failover server when the current one keeps failing.
+func restartProcesses(p *processes, pf forward.PortForwarder, c *config.Config, servers *failover.List, ctx context.Context) error {
+	metrics.VPNRestarts.Inc()
 	var (
+		hup    = true
 		notify = func(e error, t time.Duration) {
-			logger.Errorf("Failed to restart processes (retry in %v): %v", t, e)
+			logger.Event("restart_failed", logging.Fields{"retry": t, "error": e}).
+				Errorf("Failed to restart processes (retry in %v): %v", t, e)
 		}
 		operation = func() error {
-			t.Stop()
-			v.Stop()
-			return startProcesses(t, v, c, ctx)
+			conf := servers.Apply(c)
+			er := reconnect(p, pf, conf, hup, ctx)
+			if servers.Report(er) {
+				hup = false
+			}
+			return er
 		}
 		b = backoff.NewExponentialBackOff()
 	)
-	b.MaxElapsedTime = 30 * time.Minute
+	// Each try can wait c.Timeout for the tunnel and again for the port, the
+	// budget has to last until every failover server had its attempts.
+	b.MaxElapsedTime = time.Duration(servers.Tries()) * 2 * c.Timeout.Duration
+	if b.MaxElapsedTime < 30*time.Minute {
+		b.MaxElapsedTime = 30 * time.Minute
+	}
 	b.MaxInterval = 10 * time.Second
 	return backoff.RetryNotify(operation, b, notify)
 }
 
-func startProcesses(t, v *process.Process, c *config.Config, ctx context.Context) error {
-	t.SetUser(uint32(c.Transmission.UID), uint32(c.Transmission.GID))
+// reconnect restarts openvpn in place with SIGHUP when hup is set and it has
+// a management socket, and restarts everything otherwise.
+func reconnect(p *processes, pf forward.PortForwarder, c *config.Config, hup bool, ctx context.Context) error {
+	if m := p.management(c); m != nil && hup {
+		if !c.Transmission.LiveSettings {
+			p.stopTransmission()
+		}
+		logger.Infof("Restarting openvpn with SIGHUP")
+		er := m.Signal("SIGHUP")
+		if er == nil {
+			return rebind(p, pf, c, ctx)
+		}
+		logger.Warnf("Failed to signal openvpn, restarting it: %v", er)
+	}
+	p.stop()
+	return startProcesses(p, pf, c, ctx)
+}
+
+func startProcesses(p *processes, pf forward.PortForwarder, c *config.Config, ctx context.Context) error {
+	logger.Event("vpn_starting", logging.Fields{"vpn": c.VPN}).Infof("Starting %s", c.VPN)
+	if er := p.startVPN(c); er != nil {
+		return er
+	}
+	return bindTransmission(p, pf, c, ctx)
+}
 
-	logger.Infof("Starting openvpn")
-	go v.ExecuteAndRestart(ctx)
+// bindTransmission points Transmission's settings at the tunnel address and
+// a freshly forwarded port, then starts it.
+func bindTransmission(p *processes, pf forward.PortForwarder, c *config.Config, ctx context.Context) error {
+	ip, er := tunnelIP(p, c, ctx)
+	if er != nil {
+		return er
+	}
+	return startBound(ip, p, pf, c, ctx)
+}
 
-	ip, er := getIP(c.OpenVPN.Tun, c.Timeout.Duration, ctx)
+// rebind points a running Transmission at the tunnel address and a freshly
+// forwarded port. With transmission.live_settings the port is set over rpc
+// and Transmission is only restarted when the bind address changed,
+// otherwise it is always restarted.
+func rebind(p *processes, pf forward.PortForwarder, c *config.Config, ctx context.Context) error {
+	if !c.Transmission.LiveSettings || !p.transmissionRunning() {
+		p.stopTransmission()
+		return bindTransmission(p, pf, c, ctx)
+	}
+	ip, er := tunnelIP(p, c, ctx)
 	if er != nil {
 		return er
 	}
-	logger.Infof("New bind ip: (%s) %s", c.OpenVPN.Tun, ip)
 
-	port, er := getPort(ip, c.PIA.User, c.PIA.Pass, c.PIA.ClientID, c.Timeout.Duration, ctx)
+	changed, er := transmission.Changed(c.Transmission.Config, transmission.Args{config.BindKey: ip})
+	if er != nil {
+		logger.Warnf("Failed to read %s, restarting transmission: %v", c.Transmission.Config, er)
+	}
+	if er != nil || len(changed) > 0 {
+		p.stopTransmission()
+		return startBound(ip, p, pf, c, ctx)
+	}
+	logger.Event("live_rebind", logging.Fields{"ip": ip}).Infof("Bind ip %s unchanged, setting the port over rpc", ip)
+	return bindPort(ip, pf, c, ctx)
+}
+
+// startBound writes ip and a freshly forwarded port to Transmission's
+// settings and starts it.
+func startBound(ip string, p *processes, pf forward.PortForwarder, c *config.Config, ctx context.Context) error {
+	logger.Event("bind_ip", logging.Fields{"ip": ip, "device": c.Device()}).Infof("New bind ip: (%s) %s", c.Device(), ip)
+	port, er := getPort(ip, pf, c.Timeout.Duration, ctx)
 	if er != nil {
 		return er
 	}
-	logger.Infof("New peer port: %d", port)
+	logger.Event("peer_port", logging.Fields{"port": port, "ip": ip, "provider": pf.Name()}).Infof("New peer port: %d", port)
 
-	if er := transmission.UpdateSettings(c.Transmission.Config, ip, port); er != nil {
+	if er := transmission.UpdateSettings(settingsFile(c), ip, port, c.Transmission.Settings); er != nil {
 		return er
 	}
+	state.setBinding(ip, port)
 
-	logger.Infof("Starting transmission")
-	go t.ExecuteAndRestart(ctx)
+	logger.Event("transmission_starting", logging.Fields{"ip": ip, "port": port}).Infof("Starting transmission")
+	return p.startTransmission(c)
+}
 
-	return nil
+// tunnelCheck runs the health probes configured in c against the tunnel.
+func tunnelCheck(c *config.Config) error {
+	h := c.Health
+	er := (&vpn.Checker{
+		Device:  c.Device(),
+		Ping:    h.Ping,
+		URL:     h.URL,
+		IPURL:   h.IPURL,
+		HomeIP:  h.HomeIP,
+		Timeout: h.Timeout.Duration,
+	}).Check()
+	if er != nil {
+		metrics.TunnelChecks.With("failure").Inc()
+	} else {
+		metrics.TunnelChecks.With("success").Inc()
+	}
+	return er
 }
 
-func getPort(ip, user, pass, id string, timeout time.Duration, c context.Context) (int, error) {
+// leakCheck reports whether the public ip through the tunnel is the same as
+// the one through the default route. When the default route is the tunnel
+// both probes would go through it, so there is nothing to compare.
+func leakCheck(c *config.Config, ctx context.Context) (bool, error) {
+	ip, er := getIP(c.Device(), c.Timeout.Duration, ctx)
+	if er != nil {
+		return false, er
+	}
+	if dev, er := vpn.DefaultDevice(); er != nil {
+		logger.Debugf("Failed to look up the default route: %v", er)
+	} else if dev == c.Device() {
+		logger.Warnf("The default route goes through %s, skipping the leak check", dev)
+		return false, nil
+	}
+	leak, public, er := vpn.Leak(c.LeakCheck.URL, ip, c.Timeout.Duration)
+	if leak {
+		metrics.Leaks.Inc()
+		logger.Event("leak", logging.Fields{"device": c.Device(), "ip": public}).
+			Errorf("Traffic is leaking past the VPN, public ip through %s is %s", c.Device(), public)
+	}
+	return leak, er
+}
+
+// killSwitch installs the firewall rules when c enables them and returns nil
+// otherwise. The rules of old are removed first when c uses another backend,
+// the new backend would not replace them.
+func killSwitch(old *firewall.Firewall, c *config.Config) (*firewall.Firewall, error) {
+	if !c.Firewall.Enabled {
+		return nil, nil
+	}
+	fw, er := firewall.New(c)
+	if er != nil {
+		return nil, er
+	}
+	if old != nil && old.Name() != fw.Name() {
+		logger.Infof("Firewall backend changed from %s to %s", old.Name(), fw.Name())
+		old.Down()
+	}
+	return fw, fw.Up()
+}
+
+// applySettings brings Transmission to transmission.settings, restarting it
+// when a setting that changed cannot be set over rpc.
+func applySettings(p *processes, pf forward.PortForwarder, c *config.Config, ctx context.Context) error {
+	var r *transmission.RawClient
+	if p.transmissionRunning() {
+		r = rpcClient(c)
+	}
+	restart, er := transmission.ApplySettings(settingsFile(c), r, c.Transmission.Settings)
+	if er != nil || !restart || r == nil {
+		return er
+	}
+	logger.Infof("Restarting transmission for settings it cannot change live")
+	p.stopTransmission()
+	return bindTransmission(p, pf, c, ctx)
+}
+
+func rpcClient(c *config.Config) *transmission.RawClient {
+	return transmission.NewRawClient(c.Transmission.URL.String(), c.Transmission.User, c.Transmission.Pass)
+}
+
+func settingsFile(c *config.Config) *transmission.SettingsFile {
+	return &transmission.SettingsFile{Path: c.Transmission.Config, Backups: c.Transmission.Backups}
+}
+
+func countPortUpdate(er error) {
+	if er != nil {
+		metrics.PortUpdates.With("failure").Inc()
+	} else {
+		metrics.PortUpdates.With("success").Inc()
+	}
+}
+
+// needsRestart reports whether a config update touched anything the running
+// processes were started with.
+func needsRestart(u *config.Update) bool {
+	if u.Has(config.OpenVPNSection | config.VPNSection) {
+		return true
+	}
+	if !u.Has(config.TransmissionSection) {
+		return false
+	}
+	o, n := u.Old.Transmission, u.New.Transmission
+	return o.Command != n.Command || o.Config != n.Config || o.UID != n.UID || o.GID != n.GID
+}
+
+// settingsChanged reports whether a config update changed
+// transmission.settings.
+func settingsChanged(u *config.Update) bool {
+	return u.Has(config.TransmissionSection) && !reflect.DeepEqual(u.Old.Transmission.Settings, u.New.Transmission.Settings)
+}
+
+func getPort(ip string, pf forward.PortForwarder, timeout time.Duration, c context.Context) (int, error) {
 	var port int
 	notify := func(e error, w time.Duration) {
-		logger.Errorf("Failed to get port from PIA (retry in %v): %v", w, e)
+		logger.Event("port_request_failed", logging.Fields{"provider": pf.Name(), "ip": ip, "retry": w, "error": e}).
+			Errorf("Failed to get port from %s (retry in %v): %v", pf.Name(), w, e)
 	}
 	fn := func() error {
 		select {
 		default:
-			p, er := pia.RequestPort(ip, user, pass, id)
+			p, er := pf.RequestPort(ip)
 			if er != nil {
 				return er
 			}
@@ -144,10 +355,35 @@ func getPort(ip, user, pass, id string, timeout time.Duration, c context.Context
 	return port, backoff.RetryNotify(fn, b, notify)
 }
 
+// tunnelIP waits for openvpn to report CONNECTED when it has a management
+// socket and looks up the tun address otherwise.
+func tunnelIP(p *processes, c *config.Config, ctx context.Context) (string, error) {
+	m := p.management(c)
+	if m == nil {
+		return getIP(c.Device(), c.Timeout.Duration, ctx)
+	}
+
+	deadline := time.After(c.Timeout.Duration)
+	for {
+		if s := m.State(); s != nil && s.Name == vpn.StateConnected {
+			return s.LocalIP, nil
+		}
+		select {
+		case <-m.States():
+		case <-time.After(time.Second):
+		case <-deadline:
+			return "", fmt.Errorf("openvpn did not connect within %v", c.Timeout.Duration)
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
 func getIP(dev string, timeout time.Duration, c context.Context) (string, error) {
 	var address string
 	notify := func(e error, w time.Duration) {
-		logger.Errorf("Failed to get IP for %q (retry in %v): %v", dev, w, e)
+		logger.Event("ip_lookup_failed", logging.Fields{"device": dev, "retry": w, "error": e}).
+			Errorf("Failed to get IP for %q (retry in %v): %v", dev, w, e)
 	}
 	fn := func() (er error) {
 		select {
@@ -0,0 +1,179 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"strings"
+
+	"github.com/albertrdixon/transmon/transmission"
+)
+
+// maxFeed is the most of a feed or .torrent that is read.
+const maxFeed = 10 << 20
+
+const torrentType = "application/x-bittorrent"
+
+// errNotTorrent is returned for links that lead to something other than a
+// torrent, trying them again will not help.
+var errNotTorrent = errors.New("not a torrent")
+
+type item struct {
+	id, title, link string
+}
+
+// document decodes both RSS, items under channel, and Atom, entries under
+// the root.
+type document struct {
+	Items []struct {
+		Title     string `xml:"title"`
+		Link      string `xml:"link"`
+		GUID      string `xml:"guid"`
+		Enclosure struct {
+			URL string `xml:"url,attr"`
+		} `xml:"enclosure"`
+	} `xml:"channel>item"`
+	Entries []struct {
+		Title string `xml:"title"`
+		ID    string `xml:"id"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+	} `xml:"entry"`
+}
+
+// parse returns the items of an RSS or Atom feed. An item's link is its
+// enclosure, or its link when that is a magnet. Other links are mostly web
+// pages and are left empty.
+func parse(data []byte) ([]*item, error) {
+	var d document
+	if er := xml.Unmarshal(data, &d); er != nil {
+		return nil, er
+	}
+
+	items := make([]*item, 0, len(d.Items)+len(d.Entries))
+	for _, i := range d.Items {
+		it := &item{id: i.GUID, title: strings.TrimSpace(i.Title), link: i.Enclosure.URL}
+		if link := strings.TrimSpace(i.Link); it.link == "" && magnet(link) {
+			it.link = link
+		}
+		items = append(items, it)
+	}
+	for _, e := range d.Entries {
+		it := &item{id: e.ID, title: strings.TrimSpace(e.Title)}
+		for _, l := range e.Links {
+			if l.Rel == "enclosure" || l.Type == torrentType || magnet(l.Href) {
+				it.link = l.Href
+				break
+			}
+		}
+		items = append(items, it)
+	}
+
+	for _, it := range items {
+		if it.id == "" {
+			it.id = it.link
+		}
+	}
+	return items, nil
+}
+
+// poll adds the items of f that pass its filters and were not added before.
+func (i *Ingester) poll(f *feed) int {
+	data, _, er := i.get(f.URL)
+	if er != nil {
+		logger.Warnf("Failed to fetch feed %s: %v", f.URL, er)
+		return 0
+	}
+	items, er := parse(data)
+	if er != nil {
+		logger.Warnf("Failed to read feed %s: %v", f.URL, er)
+		return 0
+	}
+
+	added := 0
+	for _, it := range items {
+		key := "feed:" + f.URL + "|" + it.id
+		if _, ok := i.seen[key]; ok || !f.matches(it.title) {
+			continue
+		}
+		if it.link == "" {
+			logger.Debugf("%q in %s has no magnet or torrent link", it.title, f.URL)
+			continue
+		}
+		a, er := i.fetch(it.link, f.DownloadDir)
+		if er == errNotTorrent {
+			logger.Warnf("Skipping %q from %s, %s is not a torrent", it.title, f.URL, it.link)
+			i.seen[key] = i.now()
+			continue
+		}
+		if er != nil {
+			logger.Warnf("Failed to add %q from %s, will retry: %v", it.title, f.URL, er)
+			continue
+		}
+		i.added(key, "feed", fmt.Sprintf("%q in %s", it.title, f.URL), a)
+		if !a.Duplicate {
+			added++
+		}
+	}
+	return added
+}
+
+// fetch adds a magnet link as it is and downloads anything else to a temp
+// file to add. Downloads that are not torrents fail with errNotTorrent.
+func (i *Ingester) fetch(link, dir string) (*transmission.Added, error) {
+	if magnet(link) {
+		return i.add.AddMagnet(link, dir)
+	}
+	data, kind, er := i.get(link)
+	if er != nil {
+		return nil, er
+	}
+	if t, _, _ := mime.ParseMediaType(kind); t != torrentType && !bencoded(data) {
+		return nil, errNotTorrent
+	}
+	f, er := ioutil.TempFile("", "transmon-ingest")
+	if er != nil {
+		return nil, er
+	}
+	defer os.Remove(f.Name())
+	_, er = f.Write(data)
+	if e := f.Close(); er == nil {
+		er = e
+	}
+	if er != nil {
+		return nil, er
+	}
+	return i.add.AddFile(f.Name(), dir)
+}
+
+// get returns the body of url and its content type.
+func (i *Ingester) get(url string) ([]byte, string, error) {
+	resp, er := i.client.Get(url)
+	if er != nil {
+		return nil, "", er
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, "", fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, er := ioutil.ReadAll(io.LimitReader(resp.Body, maxFeed))
+	return data, resp.Header.Get("Content-Type"), er
+}
+
+func magnet(link string) bool {
+	return strings.HasPrefix(link, "magnet:")
+}
+
+// bencoded reports whether data looks like a torrent file served with a
+// generic content type, a bencoded dictionary with an info key.
+func bencoded(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("d")) && bytes.Contains(data, []byte("4:info"))
+}
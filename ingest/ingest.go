@@ -0,0 +1,154 @@
+// Package ingest adds torrents to Transmission from watch directories and
+// RSS or Atom feeds, remembering what it added so nothing is added twice.
+package ingest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/albertrdixon/transmon/atomicfile"
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/logging"
+	"github.com/albertrdixon/transmon/metrics"
+	"github.com/albertrdixon/transmon/transmission"
+)
+
+var logger = logging.New("ingest")
+
+// forget is how long an added item is remembered. Feeds drop items long
+// before that.
+const forget = 90 * 24 * time.Hour
+
+// Adder adds torrents to Transmission, to dir when it is not empty.
+type Adder interface {
+	AddFile(path, dir string) (*transmission.Added, error)
+	AddMagnet(link, dir string) (*transmission.Added, error)
+}
+
+// Ingester checks the watch directories and feeds of an ingest section.
+type Ingester struct {
+	conf   *config.Ingest
+	add    Adder
+	feeds  []*feed
+	seen   map[string]time.Time
+	client *http.Client
+	now    func() time.Time
+}
+
+type feed struct {
+	*config.Feed
+	include, exclude []*regexp.Regexp
+}
+
+// matches reports whether title passes the feed's filters.
+func (f *feed) matches(title string) bool {
+	for _, r := range f.exclude {
+		if r.MatchString(title) {
+			return false
+		}
+	}
+	if len(f.include) < 1 {
+		return true
+	}
+	for _, r := range f.include {
+		if r.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
+// New loads what was already added from c.StateFile. Feeds are fetched with
+// timeout.
+func New(c *config.Ingest, add Adder, timeout time.Duration) (*Ingester, error) {
+	i := &Ingester{conf: c, add: add, client: &http.Client{Timeout: timeout}, now: time.Now}
+	for _, fc := range c.Feeds {
+		f := &feed{Feed: fc}
+		for _, p := range fc.Include {
+			r, er := regexp.Compile(p)
+			if er != nil {
+				return nil, er
+			}
+			f.include = append(f.include, r)
+		}
+		for _, p := range fc.Exclude {
+			r, er := regexp.Compile(p)
+			if er != nil {
+				return nil, er
+			}
+			f.exclude = append(f.exclude, r)
+		}
+		i.feeds = append(i.feeds, f)
+	}
+
+	seen, er := load(c.StateFile)
+	if er != nil {
+		logger.Warnf("Ignoring ingest state in %s: %v", c.StateFile, er)
+	}
+	i.seen = seen
+	return i, nil
+}
+
+// Run checks every watch directory and feed once and returns how many
+// torrents it added.
+func (i *Ingester) Run() int {
+	added := 0
+	for _, w := range i.conf.Watch {
+		added += i.scan(w)
+	}
+	for _, f := range i.feeds {
+		added += i.poll(f)
+	}
+
+	for k, t := range i.seen {
+		if i.now().Sub(t) > forget {
+			delete(i.seen, k)
+		}
+	}
+	if er := save(i.conf.StateFile, i.seen); er != nil {
+		logger.Warnf("Failed to save ingest state to %s: %v", i.conf.StateFile, er)
+	}
+	return added
+}
+
+// added remembers key and logs what Transmission made of it.
+func (i *Ingester) added(key, source, what string, a *transmission.Added) {
+	i.seen[key] = i.now()
+	if a.Duplicate {
+		logger.Event("torrent_duplicate", logging.Fields{"source": source}).Infof("Transmission already has %s", what)
+		return
+	}
+	metrics.TorrentsAdded.With(source).Inc()
+	logger.Event("torrent_added", logging.Fields{"source": source, "torrent_id": a.ID, "torrent_hash": a.Hash, "torrent_name": a.Name}).
+		Infof("Added %q from %s", a.Name, what)
+}
+
+// load reads the added items. A missing file is an empty state.
+func load(file string) (map[string]time.Time, error) {
+	seen := make(map[string]time.Time)
+	data, er := ioutil.ReadFile(file)
+	if os.IsNotExist(er) {
+		return seen, nil
+	} else if er != nil {
+		return seen, er
+	}
+	if er := json.Unmarshal(data, &seen); er != nil {
+		return make(map[string]time.Time), er
+	}
+	return seen, nil
+}
+
+func save(file string, seen map[string]time.Time) error {
+	if file == "" {
+		return nil
+	}
+	data, er := json.Marshal(seen)
+	if er != nil {
+		return er
+	}
+	return atomicfile.Write(file, data)
+}
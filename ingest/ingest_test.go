@@ -0,0 +1,133 @@
+package ingest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/transmission"
+	"github.com/stretchr/testify/assert"
+)
+
+type adder struct {
+	added []string
+}
+
+func (a *adder) AddFile(path, dir string) (*transmission.Added, error) {
+	data, er := ioutil.ReadFile(path)
+	if er != nil {
+		return nil, er
+	}
+	a.added = append(a.added, fmt.Sprintf("file %s -> %s", data, dir))
+	return &transmission.Added{ID: len(a.added), Name: string(data), Hash: "abc"}, nil
+}
+
+func (a *adder) AddMagnet(link, dir string) (*transmission.Added, error) {
+	a.added = append(a.added, fmt.Sprintf("magnet %s -> %s", link, dir))
+	return &transmission.Added{ID: len(a.added), Name: link, Hash: "def"}, nil
+}
+
+const rss = `<?xml version="1.0"?>
+<rss version="2.0"><channel><title>shows</title>
+<item><title>Show S01E01 720p</title><guid>1</guid><link>magnet:?xt=urn:btih:one</link></item>
+<item><title>Show S01E01 CAM</title><guid>2</guid><link>magnet:?xt=urn:btih:cam</link></item>
+<item><title>Other S01E01 720p</title><guid>3</guid><link>magnet:?xt=urn:btih:other</link></item>
+<item><title>Show S01E02 720p</title><guid>4</guid><link>http://example.com/4</link><enclosure url="%[1]s/show2.torrent" type="application/x-bittorrent"/></item>
+<item><title>Show S01E03 720p</title><guid>5</guid><link>%[1]s/page.html</link></item>
+<item><title>Show S01E04 720p</title><guid>6</guid><enclosure url="%[1]s/page.html" type="application/x-bittorrent"/></item>
+</channel></rss>`
+
+const atom = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom"><title>isos</title>
+<entry><title>ubuntu.iso</title><id>urn:ubuntu</id><link href="http://example.com/ubuntu"/><link rel="enclosure" href="magnet:?xt=urn:btih:ubuntu"/></entry>
+<entry><title>debian.iso</title><id>urn:debian</id><link href="http://example.com/debian"/></entry>
+</feed>`
+
+func TestIngest(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		srv   *httptest.Server
+		pages int
+	)
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/shows.rss":
+			fmt.Fprintf(w, rss, srv.URL)
+		case "/isos.atom":
+			fmt.Fprint(w, atom)
+		case "/show2.torrent":
+			w.Header().Set("Content-Type", "application/x-bittorrent")
+			fmt.Fprint(w, "show2")
+		case "/page.html":
+			pages++
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, "<html></html>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	watch := filepath.Join(dir, "watch")
+	os.Mkdir(watch, 0700)
+	ioutil.WriteFile(filepath.Join(watch, "a.torrent"), []byte("a"), 0600)
+	ioutil.WriteFile(filepath.Join(watch, "b.magnet"), []byte("\nmagnet:?xt=urn:btih:b\n"), 0600)
+	ioutil.WriteFile(filepath.Join(watch, "notes.txt"), []byte("c"), 0600)
+
+	c := &config.Ingest{
+		StateFile: filepath.Join(dir, "state", "ingest.json"),
+		Watch:     []*config.WatchDir{{Path: watch, DownloadDir: "/data/watch"}},
+		Feeds: []*config.Feed{
+			{URL: srv.URL + "/shows.rss", Include: []string{`^Show S\d+E\d+`}, Exclude: []string{`(?i)\bcam\b`}, DownloadDir: "/data/shows"},
+			{URL: srv.URL + "/isos.atom"},
+			{URL: srv.URL + "/missing.rss"},
+		},
+	}
+	a := new(adder)
+	in, er := New(c, a, 5*time.Second)
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	is.Equal(5, in.Run())
+	sort.Strings(a.added)
+	is.Equal([]string{
+		"file a -> /data/watch",
+		"file show2 -> /data/shows",
+		"magnet magnet:?xt=urn:btih:b -> /data/watch",
+		"magnet magnet:?xt=urn:btih:one -> /data/shows",
+		"magnet magnet:?xt=urn:btih:ubuntu -> ",
+	}, a.added)
+	is.Equal(1, pages, "only enclosures are downloaded, not item pages")
+
+	files, _ := ioutil.ReadDir(watch)
+	names := []string{}
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	is.Equal([]string{"a.torrent.added", "b.magnet.added", "notes.txt"}, names)
+
+	is.Equal(0, in.Run(), "nothing is added twice")
+	is.Equal(1, pages, "links that are not torrents are not tried again")
+	ioutil.WriteFile(filepath.Join(watch, "again.torrent"), []byte("a"), 0600)
+	again, er := New(c, a, 5*time.Second)
+	is.NoError(er)
+	is.Equal(0, again.Run(), "what was added is kept in the state file")
+	_, er = os.Stat(filepath.Join(watch, "again.torrent.added"))
+	is.NoError(er, "files added before are still moved aside")
+
+	_, er = New(&config.Ingest{Feeds: []*config.Feed{{URL: srv.URL, Include: []string{"("}}}}, a, time.Second)
+	is.Error(er)
+}
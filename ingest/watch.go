@@ -0,0 +1,81 @@
+package ingest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/transmission"
+)
+
+// scan adds the .torrent and .magnet files in w and renames them to
+// name.added once Transmission has them, like its own watch-dir does.
+func (i *Ingester) scan(w *config.WatchDir) int {
+	files, er := ioutil.ReadDir(w.Path)
+	if er != nil {
+		logger.Warnf("Failed to read watch directory %s: %v", w.Path, er)
+		return 0
+	}
+
+	added := 0
+	for _, info := range files {
+		ext := strings.ToLower(filepath.Ext(info.Name()))
+		if info.IsDir() || (ext != ".torrent" && ext != ".magnet") {
+			continue
+		}
+		path := filepath.Join(w.Path, info.Name())
+		data, er := ioutil.ReadFile(path)
+		if er != nil {
+			logger.Warnf("Failed to read %s: %v", path, er)
+			continue
+		}
+
+		sum := sha1.Sum(data)
+		key := "file:" + hex.EncodeToString(sum[:])
+		if _, ok := i.seen[key]; ok {
+			logger.Debugf("%s was already added", path)
+			done(path)
+			continue
+		}
+
+		var a *transmission.Added
+		if ext == ".torrent" {
+			a, er = i.add.AddFile(path, w.DownloadDir)
+		} else if link := magnetLink(string(data)); link != "" {
+			a, er = i.add.AddMagnet(link, w.DownloadDir)
+		} else {
+			logger.Warnf("%s has no magnet link", path)
+			continue
+		}
+		if er != nil {
+			logger.Warnf("Failed to add %s, will retry: %v", path, er)
+			continue
+		}
+		i.added(key, "watch", path, a)
+		if !a.Duplicate {
+			added++
+		}
+		done(path)
+	}
+	return added
+}
+
+func done(path string) {
+	if er := os.Rename(path, path+".added"); er != nil {
+		logger.Warnf("Failed to rename %s: %v", path, er)
+	}
+}
+
+// magnetLink is the first line of a .magnet file that is a magnet link.
+func magnetLink(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "magnet:?") {
+			return line
+		}
+	}
+	return ""
+}
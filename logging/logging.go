@@ -0,0 +1,280 @@
+// Package logging writes transmon's logs as text through gearbox's logger
+// or as one json object per line. Json entries carry the component that
+// logged them, an event name and any fields given with Event or With.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/albertrdixon/gearbox/logger"
+)
+
+// Log formats.
+const (
+	Text = "text"
+	JSON = "json"
+)
+
+var (
+	Formats = []string{Text, JSON}
+	Levels  = logger.Levels
+)
+
+// Fields are extra values for a json entry.
+type Fields map[string]interface{}
+
+var out = struct {
+	sync.Mutex
+	format string
+	w      io.Writer
+}{format: Text}
+
+// Configure sets up gearbox's logger for the level and format. Lines logged
+// through gearbox directly are turned into json entries too.
+func Configure(level, format string, w io.Writer) {
+	if w == nil {
+		w = os.Stdout
+	}
+	out.Lock()
+	out.format, out.w = format, w
+	out.Unlock()
+
+	if format != JSON {
+		logger.Configure(level, "[transmon] ", w)
+		return
+	}
+	logger.Configure(level, "", textWriter{})
+	log.SetFlags(0)
+}
+
+// Logger logs for one component.
+type Logger struct {
+	component string
+	event     string
+	fields    Fields
+}
+
+func New(component string) *Logger {
+	return &Logger{component: component}
+}
+
+// Event names the next entry and adds fields to it.
+func (l *Logger) Event(name string, f Fields) *Logger {
+	n := l.With(f)
+	n.event = name
+	return n
+}
+
+// With adds fields to every entry of the returned Logger.
+func (l *Logger) With(f Fields) *Logger {
+	n := &Logger{component: l.component, event: l.event, fields: make(Fields, len(l.fields)+len(f))}
+	for k, v := range l.fields {
+		n.fields[k] = v
+	}
+	for k, v := range f {
+		n.fields[k] = v
+	}
+	return n
+}
+
+func (l *Logger) Debugf(f string, m ...interface{}) {
+	if logger.IsDebug() {
+		l.write("debug", f, m)
+	}
+}
+func (l *Logger) Infof(f string, m ...interface{})  { l.write("info", f, m) }
+func (l *Logger) Warnf(f string, m ...interface{})  { l.write("warn", f, m) }
+func (l *Logger) Errorf(f string, m ...interface{}) { l.write("error", f, m) }
+func (l *Logger) Fatalf(f string, m ...interface{}) {
+	l.write("fatal", f, m)
+	os.Exit(1)
+}
+
+func (l *Logger) write(level, f string, m []interface{}) {
+	out.Lock()
+	format := out.format
+	out.Unlock()
+	if format != JSON {
+		text(level, f, m)
+		return
+	}
+	if !enabled(level) {
+		return
+	}
+
+	event := l.event
+	if event == "" {
+		event = EventName(f)
+	}
+	e := entry(level, l.component, event, sprintf(f, m))
+	for k, v := range l.fields {
+		if _, ok := e[k]; !ok {
+			e[k] = value(v)
+		}
+	}
+	emit(e)
+}
+
+// text hands an entry to gearbox, which adds the [level] tag and filters.
+func text(level, f string, m []interface{}) {
+	switch level {
+	case "debug":
+		logger.Debugf(f, m...)
+	case "info":
+		logger.Infof(f, m...)
+	case "warn":
+		logger.Warnf(f, m...)
+	case "error":
+		logger.Errorf(f, m...)
+	case "fatal":
+		log.Printf("[fatal] %s", sprintf(f, m))
+	}
+}
+
+func enabled(level string) bool {
+	min := string(logger.Level())
+	for _, l := range logger.Levels {
+		if l == level {
+			return true
+		}
+		if l == min {
+			return false
+		}
+	}
+	return false
+}
+
+func entry(level, component, event, msg string) Fields {
+	return Fields{
+		"time":      time.Now().UTC().Format(time.RFC3339Nano),
+		"level":     level,
+		"component": component,
+		"event":     event,
+		"msg":       msg,
+	}
+}
+
+// value keeps durations and errors readable in json.
+func value(v interface{}) interface{} {
+	switch t := v.(type) {
+	case time.Duration:
+		return t.String()
+	case error:
+		return t.Error()
+	case fmt.Stringer:
+		return t.String()
+	}
+	return v
+}
+
+func emit(e Fields) {
+	b, er := json.Marshal(e)
+	if er != nil {
+		b, _ = json.Marshal(entry("error", "logging", "marshal_failed", er.Error()))
+	}
+	out.Lock()
+	defer out.Unlock()
+	out.w.Write(append(b, '\n'))
+}
+
+func sprintf(f string, m []interface{}) string {
+	if len(m) < 1 {
+		return f
+	}
+	return fmt.Sprintf(f, m...)
+}
+
+// Writer is for child process output, which gearbox writes as "[name] line"
+// lines. With json logs each line becomes an output entry of l, with text
+// logs the lines are written as they are.
+func (l *Logger) Writer() io.Writer {
+	return outputWriter{l}
+}
+
+type outputWriter struct {
+	l *Logger
+}
+
+func (o outputWriter) Write(p []byte) (int, error) {
+	out.Lock()
+	format, w := out.format, out.w
+	out.Unlock()
+	if format != JSON {
+		if w == nil {
+			w = os.Stdout
+		}
+		return w.Write(p)
+	}
+	prefix := "[" + o.l.component + "] "
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		o.l.Event("output", nil).Infof("%s", strings.TrimPrefix(line, prefix))
+	}
+	return len(p), nil
+}
+
+// textWriter receives what gearbox logs directly, after its level filter,
+// as "[level] message" lines.
+type textWriter struct{}
+
+var tagged = regexp.MustCompile(`^\[(\w+)\] (.*)$`)
+
+func (textWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		level, msg := "info", line
+		if m := tagged.FindStringSubmatch(line); m != nil {
+			level, msg = m[1], m[2]
+		}
+		emit(entry(level, "transmon", EventName(msg), msg))
+	}
+	return len(p), nil
+}
+
+var (
+	verbs    = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+	brackets = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)`)
+)
+
+// EventName makes an event name out of the start of a format string, so
+// entries without an explicit event can still be grouped. "Failed to get IP
+// for %q (retry in %v): %v" becomes failed_to_get_ip_for.
+func EventName(f string) string {
+	f = brackets.ReplaceAllString(f, " ")
+	if i := strings.Index(f, ":"); i > -1 {
+		f = f[:i]
+	}
+	f = verbs.ReplaceAllString(f, " ")
+
+	var b bytes.Buffer
+	words := 0
+	for _, w := range strings.Fields(strings.ToLower(f)) {
+		w = strings.Map(func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return -1
+		}, w)
+		if w == "" {
+			continue
+		}
+		if words > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteString(w)
+		if words++; words == 5 {
+			break
+		}
+	}
+	if b.Len() < 1 {
+		return "log"
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func entries(b *bytes.Buffer) []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		e := map[string]interface{}{}
+		if er := json.Unmarshal([]byte(line), &e); er == nil {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestJSON(t *testing.T) {
+	is := assert.New(t)
+	var b bytes.Buffer
+	Configure("info", JSON, &b)
+	defer Configure("info", Text, nil)
+
+	l := New("cleaner").With(Fields{"torrent_id": 3})
+	l.Event("torrent_removed", Fields{"retry": 2 * time.Second, "error": errors.New("boom")}).Infof("Removed %q", "ubuntu.iso")
+	l.Debugf("Not logged at info")
+	New("pia").Warnf("Failed to get port from %s (retry in %v): %v", "pia", time.Second, "nope")
+	log.Printf("[error] from gearbox")
+
+	got := entries(&b)
+	if !is.Len(got, 3) {
+		t.FailNow()
+	}
+	is.Equal("cleaner", got[0]["component"])
+	is.Equal("torrent_removed", got[0]["event"])
+	is.Equal("info", got[0]["level"])
+	is.Equal(`Removed "ubuntu.iso"`, got[0]["msg"])
+	is.Equal(float64(3), got[0]["torrent_id"])
+	is.Equal("2s", got[0]["retry"])
+	is.Equal("boom", got[0]["error"])
+
+	is.Equal("pia", got[1]["component"])
+	is.Equal("failed_to_get_port_from", got[1]["event"])
+	is.Equal("warn", got[1]["level"])
+
+	is.Equal("transmon", got[2]["component"])
+	is.Equal("error", got[2]["level"])
+	is.Equal("from gearbox", got[2]["msg"])
+}
+
+func TestWriter(t *testing.T) {
+	is := assert.New(t)
+	var b bytes.Buffer
+	Configure("info", JSON, &b)
+	defer Configure("info", Text, nil)
+
+	w := New("openvpn").Writer()
+	w.Write([]byte("[openvpn] Initialization Sequence Completed\n"))
+	w.Write([]byte("[openvpn] {\"not\": \"an entry\"}\n"))
+	got := entries(&b)
+	if is.Len(got, 2) {
+		is.Equal("openvpn", got[0]["component"])
+		is.Equal("output", got[0]["event"])
+		is.Equal("Initialization Sequence Completed", got[0]["msg"])
+		is.Equal(`{"not": "an entry"}`, got[1]["msg"])
+	}
+
+	b.Reset()
+	Configure("info", Text, &b)
+	w.Write([]byte("[openvpn] plain\n"))
+	is.Equal("[openvpn] plain\n", b.String())
+}
+
+func TestEventName(t *testing.T) {
+	is := assert.New(t)
+	tests := map[string]string{
+		"Failed to get IP for %q (retry in %v): %v": "failed_to_get_ip_for",
+		"[Torrent %d: %q] Checking status":          "checking_status",
+		"New peer port: %d":                         "new_peer_port",
+		"%v":                                        "log",
+		"Port update will run once every %v":        "port_update_will_run_once",
+	}
+	for f, name := range tests {
+		is.Equal(name, EventName(f), f)
+	}
+}
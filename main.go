@@ -1,156 +1,842 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"os/signal"
 	"runtime"
+	"sync"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"golang.org/x/net/context"
 
-	"github.com/albertrdixon/gearbox/logger"
-	"github.com/albertrdixon/gearbox/process"
+	"github.com/albertrdixon/transmon/api"
 	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/failover"
+	"github.com/albertrdixon/transmon/firewall"
+	"github.com/albertrdixon/transmon/forward"
+	"github.com/albertrdixon/transmon/ingest"
+	"github.com/albertrdixon/transmon/logging"
+	"github.com/albertrdixon/transmon/notify"
+	"github.com/albertrdixon/transmon/pia"
 	"github.com/albertrdixon/transmon/transmission"
+	"github.com/albertrdixon/transmon/vpn"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 var (
+	logger    = logging.New("worker")
+	cleanLog  = logging.New("cleaner")
 	logLevels = []string{"fatal", "error", "warn", "info", "debug"}
 	app       = kingpin.New("transmon", "Keep your transmission ports clear!")
 
-	conf  = app.Flag("config", "config file").Short('C').Default("/etc/transmon/config.yml").OverrideDefaultFromEnvar("CONFIG").ExistingFile()
-	level = app.Flag("log-level", "log level. One of: fatal, error, warn, info, debug").Short('l').Default("info").OverrideDefaultFromEnvar("LOG_LEVEL").Enum(logger.Levels...)
-)
+	conf      = app.Flag("config", "config file").Short('C').Default("/etc/transmon/config.yml").OverrideDefaultFromEnvar("CONFIG").ExistingFile()
+	level     = app.Flag("log-level", "log level. One of: fatal, error, warn, info, debug").Short('l').Default("info").OverrideDefaultFromEnvar("LOG_LEVEL").Enum(logging.Levels...)
+	logFormat = app.Flag("log-format", "log format. One of: text, json").Default(logging.Text).OverrideDefaultFromEnvar("LOG_FORMAT").Enum(logging.Formats...)
+	dryRun    = app.Flag("dry-run", "only log what the torrent cleaner would do").OverrideDefaultFromEnvar("DRY_RUN").Bool()
+
+	runCmd    = app.Command("run", "run transmission and the vpn, keeping the peer port forwarded").Default()
+	cleanCmd  = app.Command("clean", "run the torrent cleaner once")
+	cleanJSON = cleanCmd.Flag("json", "print cleaner decisions as json").Bool()
+	fwCmd     = app.Command("firewall", "install or remove the kill switch rules")
+	fwAction  = fwCmd.Arg("action", "up, down or script. script prints the rules without applying them").Default("script").Enum("up", "down", "script")
 
-const (
-	portInterval    = 1 * time.Hour
-	restartInterval = 24 * time.Hour
-	checkInterval   = 5 * time.Minute
-	cleanInterval   = 30 * time.Minute
+	vpnCmd       = app.Command("vpn", "PIA regions and openvpn configs")
+	regionsCmd   = vpnCmd.Command("regions", "list the PIA regions")
+	regionsURL   = regionsCmd.Flag("refresh", "fetch the region list from this url").String()
+	ovpnCmd      = vpnCmd.Command("config", "write the openvpn config for a PIA region")
+	ovpnRegion   = ovpnCmd.Arg("region", "region id, name or host").Required().String()
+	ovpnProtocol = ovpnCmd.Flag("proto", "udp or tcp").Default("udp").Enum("udp", "tcp")
+	ovpnCipher   = ovpnCmd.Flag("cipher", "aes-128-cbc or aes-256-cbc").Default(pia.AES128).Enum(pia.AES128, pia.AES256)
+	ovpnCA       = ovpnCmd.Flag("ca", "PIA ca certificate, defaults to pia.openvpn.ca").String()
+	ovpnDir      = ovpnCmd.Flag("dir", "where to write the config").Default(".").String()
+	ovpnRefresh  = ovpnCmd.Flag("refresh", "fetch the region list from this url, defaults to pia.openvpn.regions_url").String()
+
+	diffCmd  = app.Command("diff", "show where transmission differs from transmission.settings")
+	diffLive = diffCmd.Flag("live", "compare with the running transmission over rpc instead of its settings file").Bool()
 )
 
-func workers(conf *config.Config, c context.Context, quit context.CancelFunc) {
+func workers(w *config.Watcher, c context.Context, quit context.CancelFunc) {
 	var (
-		port    = time.NewTicker(portInterval)
-		restart = time.NewTicker(restartInterval)
-		check   = time.NewTicker(checkInterval)
+		in      = w.Config().Intervals
+		port    = time.NewTicker(in.Port.Duration)
+		check   = time.NewTicker(in.Check.Duration)
+		restart = time.NewTimer(untilRestart(in))
+		health  = time.NewTicker(w.Config().Health.Interval.Duration)
+		leak    = time.NewTicker(w.Config().LeakCheck.Interval.Duration)
+		failed  int
+		renew   = new(renewal)
+		updates = w.Subscribe()
+		procs   = newProcesses()
+		fw      *firewall.Firewall
+		servers = failover.New(w.Config().Failover)
+		// bound is when starting or restarting last bound Transmission,
+		// connections from before then need no binding of their own.
+		bound time.Time
 	)
+	stop := func() {
+		port.Stop()
+		restart.Stop()
+		check.Stop()
+		health.Stop()
+		leak.Stop()
+		renew.stop()
+		procs.shutdown()
+		if fw != nil {
+			fw.Down()
+		}
+	}
+	die := func(er error) {
+		stop()
+		fatalf(nil, "%v", er)
+	}
+	// current is the config pointed at the failover server in use.
+	current := func() *config.Config {
+		return servers.Apply(w.Config())
+	}
+	restartAll := func(pf forward.PortForwarder) {
+		failed = 0
+		er := restartProcesses(procs, pf, w.Config(), servers, c)
+		bound = time.Now()
+		state.setServer(servers.Current())
+		if er != nil {
+			die(er)
+		}
+		msg := fmt.Sprintf("%s restarted, bound to %s", w.Config().VPN, state.IP())
+		if servers != nil {
+			msg = fmt.Sprintf("%s restarted on %s, bound to %s", w.Config().VPN, servers.Current(), state.IP())
+		}
+		notify.Send(config.EventVPNRestarted, "transmon: vpn restarted", msg,
+			map[string]string{"vpn": w.Config().VPN, "server": servers.Current(), "ip": state.IP()})
+	}
 
-	logger.Infof("Port update will run once every hour")
-	logger.Infof("VPN restart will run once every day")
+	logIntervals(in)
+	logHealth(w.Config().Health)
+	logLeakCheck(w.Config().LeakCheck)
 
-	trans, er := process.New("transmission", conf.Transmission.Command, os.Stdout)
+	pf, er := forward.New(w.Config())
 	if er != nil {
 		quit()
-		logger.Fatalf(er.Error())
+		die(er)
 	}
-	vpn, er := process.New("openvpn", conf.OpenVPN.Command, os.Stdout)
-	if er != nil {
-		quit()
-		logger.Fatalf(er.Error())
+	logger.Infof("Using %s port forwarding", pf.Name())
+	renew.reset(pf)
+	state.setProvider(pf.Name())
+	state.setProcesses(procs)
+	state.setServer(servers.Current())
+	if servers != nil {
+		logger.Infof("Failing over between %d servers, starting with %s", len(w.Config().Failover.Servers), servers.Current())
 	}
 
-	if er := startProcesses(trans, vpn, conf, c); er != nil {
+	if fw, er = killSwitch(nil, current()); er != nil {
 		quit()
-		logger.Fatalf(er.Error())
+		die(er)
 	}
-	portUpdate(conf, c)
+	er = startProcesses(procs, pf, current(), c)
+	bound = time.Now()
+	servers.Report(er)
+	if er != nil && servers == nil {
+		quit()
+		die(er)
+	} else if er != nil {
+		logger.Errorf("Failed to start on %s: %v", servers.Current(), er)
+		restartAll(pf)
+	}
+	portUpdate(pf, current(), c)
 
 	for {
+		// Whatever mapped the port last may have been granted another lifetime.
+		renew.rearm()
 		select {
 		case <-c.Done():
-			port.Stop()
-			restart.Stop()
-			trans.Stop()
-			vpn.Stop()
+			stop()
 			return
+		case u := <-updates:
+			if u.Has(config.IntervalsSection) {
+				in = u.New.Intervals
+				port.Stop()
+				check.Stop()
+				port, check = time.NewTicker(in.Port.Duration), time.NewTicker(in.Check.Duration)
+				resetTimer(restart, untilRestart(in))
+				logIntervals(in)
+			}
+			if u.Has(config.HealthSection) {
+				health.Stop()
+				health, failed = time.NewTicker(u.New.Health.Interval.Duration), 0
+				logHealth(u.New.Health)
+			}
+			if u.Has(config.LeakSection) {
+				leak.Stop()
+				leak = time.NewTicker(u.New.LeakCheck.Interval.Duration)
+				logLeakCheck(u.New.LeakCheck)
+			}
+			if u.Has(config.ForwardingSection | config.PIASection) {
+				npf, er := forward.New(u.New)
+				if er != nil {
+					logger.Errorf("Keeping %s port forwarding, new config is invalid: %v", pf.Name(), er)
+				} else {
+					logger.Infof("Port forwarding config changed, using %s", npf.Name())
+					pf = npf
+					renew.reset(pf)
+					state.setProvider(pf.Name())
+				}
+			}
+			moved := false
+			if u.Has(config.FailoverSection) {
+				old := servers.Current()
+				servers = failover.New(u.New.Failover)
+				moved = servers.Current() != old
+				state.setServer(servers.Current())
+			}
+			if u.Has(config.FirewallSection | config.OpenVPNSection | config.VPNSection | config.TransmissionSection | config.FailoverSection) {
+				nfw, er := killSwitch(fw, servers.Apply(u.New))
+				switch {
+				case er != nil:
+					logger.Errorf("Keeping the old kill switch, new config failed: %v", er)
+					if fw != nil {
+						fw.Up()
+					}
+				case nfw == nil && fw != nil:
+					fw.Down()
+					fw = nil
+				default:
+					fw = nfw
+				}
+			}
+			if needsRestart(u) || moved {
+				logger.Infof("Process config changed, restarting Transmission and the VPN")
+				restartAll(pf)
+				continue
+			}
+			if u.Has(config.ForwardingSection | config.PIASection) {
+				logger.Infof("Updating Transmission port after config change")
+				if er := portUpdate(pf, servers.Apply(u.New), c); er != nil {
+					logger.Errorf("Failed to update port after config change: %v", er)
+				}
+			}
+			if settingsChanged(u) {
+				logger.Infof("Transmission settings changed, applying them")
+				if er := applySettings(procs, pf, servers.Apply(u.New), c); er != nil {
+					logger.Errorf("Failed to apply transmission settings: %v", er)
+				}
+			}
 		case t := <-check.C:
 			logger.Debugf("Checking transmission port at %v", t)
-			if er := portCheck(trans, conf, c); er != nil {
-				if er := restartProcesses(trans, vpn, conf, c); er != nil {
-					port.Stop()
-					restart.Stop()
-					trans.Stop()
-					vpn.Stop()
-					logger.Fatalf(er.Error())
+			conf := current()
+			if er := portCheck(procs, pf, conf, c); er != nil {
+				restartAll(pf)
+			}
+		case <-health.C:
+			conf := current()
+			if !conf.Health.Enabled {
+				continue
+			}
+			if er := tunnelCheck(conf); er != nil {
+				failed++
+				logger.Event("health_check_failed", logging.Fields{"failures": failed, "error": er}).
+					Warnf("Tunnel health check failed (%d/%d): %v", failed, conf.Health.Failures, er)
+				if failed < conf.Health.Failures {
+					continue
 				}
+				logger.Errorf("Tunnel unhealthy, restarting Transmission and the VPN")
+				restartAll(pf)
+				continue
+			}
+			if failed > 0 {
+				logger.Infof("Tunnel healthy again")
+			}
+			failed = 0
+		case s := <-procs.states():
+			logger.Event("vpn_state", logging.Fields{"state": s.Name, "ip": s.LocalIP}).Infof("OpenVPN state: %v", s)
+			if s.Name != vpn.StateConnected {
+				continue
+			}
+			// State times are whole seconds.
+			if s.Time.Before(bound.Truncate(time.Second)) {
+				logger.Debugf("Connection at %v was already bound", s.Time)
+				continue
+			}
+			conf := current()
+			if s.LocalIP != state.IP() {
+				logger.Infof("Tunnel address changed, rebinding Transmission")
+				if er := rebind(procs, pf, conf, c); er != nil {
+					restartAll(pf)
+				}
+			} else if er := bindPort(s.LocalIP, pf, conf, c); er != nil {
+				restartAll(pf)
+			}
+		case <-leak.C:
+			conf := current()
+			if !conf.LeakCheck.Enabled {
+				continue
+			}
+			leaking, er := leakCheck(conf, c)
+			if er != nil {
+				logger.Warnf("Leak check failed: %v", er)
+			}
+			if leaking && conf.LeakCheck.Action == config.LeakRestart {
+				logger.Errorf("Restarting Transmission and the VPN after leak")
+				restartAll(pf)
 			}
 		case t := <-port.C:
 			logger.Infof("Update of Transmission port at %v", t)
-			if er := portUpdate(conf, c); er != nil {
-				if er := restartProcesses(trans, vpn, conf, c); er != nil {
-					port.Stop()
-					restart.Stop()
-					trans.Stop()
-					vpn.Stop()
-					logger.Fatalf(er.Error())
+			conf := current()
+			if er := portUpdate(pf, conf, c); er != nil {
+				restartAll(pf)
+			}
+		case <-state.refresh:
+			logger.Infof("Port refresh requested")
+			conf := current()
+			if er := portUpdate(pf, conf, c); er != nil {
+				restartAll(pf)
+			}
+		case <-renew.c():
+			conf := current()
+			if er := portRenew(renew.r, conf, c); er != nil {
+				logger.Warnf("Failed to renew %s port, requesting a new one: %v", pf.Name(), er)
+				if er := portUpdate(pf, conf, c); er != nil {
+					restartAll(pf)
 				}
 			}
 		case t := <-restart.C:
-			logger.Infof("Restarting Transmission and OpenVPN at %v", t)
-			if er := restartProcesses(trans, vpn, conf, c); er != nil {
-				port.Stop()
-				restart.Stop()
-				trans.Stop()
-				vpn.Stop()
-				logger.Fatalf(er.Error())
-			}
+			logger.Infof("Restarting Transmission and the VPN at %v", t)
+			conf := current()
+			restartAll(pf)
+			restart.Reset(untilRestart(conf.Intervals))
+		case <-state.restart:
+			logger.Infof("VPN restart requested")
+			restartAll(pf)
 		}
 	}
 }
 
-func cleaner(conf *config.Config, c context.Context) {
+func untilRestart(in *config.Intervals) time.Duration {
+	now := time.Now()
+	return in.NextRestart(now).Sub(now)
+}
+
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
+
+func logIntervals(in *config.Intervals) {
+	logger.Infof("Port update will run once every %v", in.Port.Duration)
+	logger.Infof("Port check will run once every %v", in.Check.Duration)
+	if in.RestartAt != nil {
+		logger.Infof("VPN restart will run daily at %v", in.RestartAt)
+	} else {
+		logger.Infof("VPN restart will run once every %v", in.Restart.Duration)
+	}
+}
+
+func logHealth(h *config.Health) {
+	if !h.Enabled {
+		logger.Infof("Tunnel health check is disabled")
+		return
+	}
+	logger.Infof("Tunnel health check will run once every %v, restarting after %d failures", h.Interval.Duration, h.Failures)
+}
+
+func logLeakCheck(l *config.LeakCheck) {
+	if !l.Enabled {
+		logger.Infof("Leak check is disabled")
+		return
+	}
+	logger.Infof("Leak check will run once every %v, action on leak: %s", l.Interval.Duration, l.Action)
+}
+
+// renewal ticks for forwarders that need their mapping refreshed and never
+// fires for the rest.
+type renewal struct {
+	r    forward.Renewer
+	t    *time.Ticker
+	d    time.Duration
+	name string
+}
+
+func (r *renewal) reset(pf forward.PortForwarder) {
+	r.stop()
+	if rn, ok := pf.(forward.Renewer); ok {
+		r.r, r.name = rn, pf.Name()
+		r.rearm()
+	}
+}
+
+// rearm restarts the ticker when the renew interval moved, as it does once
+// the gateway grants a lifetime other than the one asked for.
+func (r *renewal) rearm() {
+	if r.r == nil {
+		return
+	}
+	d := r.r.RenewInterval()
+	if r.t != nil && d == r.d {
+		return
+	}
+	if r.t != nil {
+		r.t.Stop()
+	}
+	logger.Infof("%s port will be renewed every %v", r.name, d)
+	r.t, r.d = time.NewTicker(d), d
+}
+
+func (r *renewal) c() <-chan time.Time {
+	if r.t == nil {
+		return nil
+	}
+	return r.t.C
+}
+
+func (r *renewal) stop() {
+	if r.t != nil {
+		r.t.Stop()
+	}
+	r.r, r.t, r.d = nil, nil, 0
+}
+
+func cleaner(w *config.Watcher, c context.Context) {
 	var (
-		d     = conf.Cleaner.Interval.Duration
-		clean = time.NewTicker(d)
+		d       = w.Config().Cleaner.Interval.Duration
+		clean   = time.NewTicker(d)
+		updates = w.Subscribe()
 	)
-	logger.Infof("Torrent cleaner will run once every %v", d)
+	cleanLog.Infof("Torrent cleaner will run once every %v", d)
+	if !w.Config().Cleaner.Enabled {
+		cleanLog.Infof("Torrent cleaner is disabled until enabled in config")
+	}
 	for {
 		select {
 		case <-c.Done():
 			clean.Stop()
 			return
+		case u := <-updates:
+			if !u.Has(config.CleanerSection) {
+				continue
+			}
+			if nd := u.New.Cleaner.Interval.Duration; nd != d {
+				clean.Stop()
+				d, clean = nd, time.NewTicker(nd)
+				cleanLog.Infof("Torrent cleaner will now run once every %v", d)
+			}
+			if u.Old.Cleaner.Enabled != u.New.Cleaner.Enabled {
+				cleanLog.Infof("Torrent cleaner enabled: %v", u.New.Cleaner.Enabled)
+			}
 		case t := <-clean.C:
-			logger.Infof("Half hourly torrent cleaning at %v", t)
-			er := transmission.
-				NewClient(conf.Transmission.URL.String(), conf.Transmission.User, conf.Transmission.Pass).
-				CleanTorrents()
-			if er != nil {
-				logger.Errorf(er.Error())
+			conf := w.Config()
+			if !conf.Cleaner.Enabled {
+				continue
 			}
+			cleanLog.Infof("Torrent cleaning at %v", t)
+			cleanTorrents(conf)
+		case <-state.clean:
+			cleanLog.Infof("Torrent cleaning requested")
+			cleanTorrents(w.Config())
 		}
 	}
 }
 
+// ingester adds torrents from the watch directories and feeds of the
+// ingest section at start and every ingest.interval.
+func ingester(w *config.Watcher, c context.Context) {
+	var (
+		d       = w.Config().Ingest.Interval.Duration
+		tick    = time.NewTicker(d)
+		updates = w.Subscribe()
+		in      = newIngester(w.Config())
+	)
+	defer func() { tick.Stop() }()
+	if in != nil {
+		in.Run()
+	}
+	for {
+		select {
+		case <-c.Done():
+			return
+		case u := <-updates:
+			if !u.Has(config.IngestSection | config.TransmissionSection) {
+				continue
+			}
+			in = newIngester(u.New)
+			if nd := u.New.Ingest.Interval.Duration; nd != d {
+				tick.Stop()
+				d, tick = nd, time.NewTicker(nd)
+			}
+		case <-tick.C:
+			if in != nil {
+				in.Run()
+			}
+		}
+	}
+}
+
+// newIngester returns nil when conf has nothing to watch.
+func newIngester(conf *config.Config) *ingest.Ingester {
+	ic := conf.Ingest
+	if !ic.Enabled() {
+		return nil
+	}
+	in, er := ingest.New(ic, newCleaner(conf), ic.Timeout.Duration)
+	if er != nil {
+		logger.Errorf("Not adding torrents from watch directories and feeds: %v", er)
+		return nil
+	}
+	logger.Infof("Adding torrents from %d watch directories and %d feeds every %v", len(ic.Watch), len(ic.Feeds), ic.Interval.Duration)
+	return in
+}
+
+func cleanTorrents(conf *config.Config) {
+	decisions, er := newCleaner(conf).CleanTorrents(conf.Cleaner.Rules, *dryRun)
+	if er != nil {
+		cleanLog.Event("cleaner_failed", nil).Errorf("%v", er)
+		return
+	}
+	state.setCleaned(time.Now())
+	for _, d := range decisions {
+		if !d.Applied {
+			continue
+		}
+		notify.Send(config.EventTorrentCleaned, "transmon: torrent cleaned",
+			fmt.Sprintf("%s: %s (rule %s: %s)", d.Name, d.Action, d.Rule, d.Reason),
+			map[string]string{"name": d.Name, "hash": d.Hash, "action": d.Action, "rule": d.Rule, "reason": d.Reason})
+	}
+}
+
+// notifications keeps the dispatcher in step with the config and looks for
+// finished torrents. It returns once the first dispatcher is in use and
+// keeps going in a goroutine started with spawn.
+func notifications(w *config.Watcher, c context.Context, spawn func(func())) {
+	var (
+		cancel   context.CancelFunc
+		updates  = w.Subscribe()
+		interval = w.Config().Notify.Interval.Duration
+		finished = time.NewTicker(interval)
+		complete map[string]bool
+	)
+	use := func(n *config.Notifications) {
+		d, er := notify.NewDispatcher(n)
+		if er != nil {
+			logger.Errorf("Keeping the old notification sinks, new config failed: %v", er)
+			return
+		}
+		if cancel != nil {
+			cancel()
+		}
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(c)
+		go d.Run(ctx)
+		notify.Use(d)
+		if d != nil {
+			logger.Infof("Sending notifications to %d sinks", len(n.Sinks))
+		}
+	}
+	use(w.Config().Notify)
+
+	spawn(func() {
+		defer func() { finished.Stop() }()
+		for {
+			select {
+			case <-c.Done():
+				return
+			case u := <-updates:
+				if !u.Has(config.NotifySection) {
+					continue
+				}
+				use(u.New.Notify)
+				if nd := u.New.Notify.Interval.Duration; nd != interval {
+					finished.Stop()
+					interval, finished = nd, time.NewTicker(nd)
+				}
+			case <-finished.C:
+				conf := w.Config()
+				if len(conf.Notify.Sinks) < 1 {
+					complete = nil
+					continue
+				}
+				complete = finishedTorrents(conf, complete)
+			}
+		}
+	})
+}
+
+// finishedTorrents sends a notification for every torrent that completed
+// since the last look and returns the hashes of complete torrents. Nothing
+// is sent on the first look, when last is nil.
+func finishedTorrents(conf *config.Config, last map[string]bool) map[string]bool {
+	torrents, er := rpcClient(conf).TorrentGet([]string{"hashString", "name", "percentDone", "downloadDir"})
+	if er != nil {
+		logger.Debugf("Failed to look for finished torrents: %v", er)
+		return last
+	}
+	complete := make(map[string]bool, len(torrents))
+	for _, t := range torrents {
+		if !t.Complete() {
+			continue
+		}
+		complete[t.HashString] = true
+		if last != nil && !last[t.HashString] {
+			notify.Send(config.EventTorrentFinished, "transmon: torrent finished", t.Name,
+				map[string]string{"name": t.Name, "hash": t.HashString, "dir": t.DownloadDir})
+		}
+	}
+	return complete
+}
+
+func newCleaner(conf *config.Config) *transmission.Client {
+	c := transmission.NewClient(conf.Transmission.URL.String(), conf.Transmission.User, conf.Transmission.Pass)
+	c.StateFile = conf.Cleaner.StateFile
+	return c
+}
+
 func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 	kingpin.Version(version)
-	kingpin.MustParse(app.Parse(os.Args[1:]))
-	logger.Configure(*level, "[transmon] ", os.Stdout)
+	cmd := kingpin.MustParse(app.Parse(os.Args[1:]))
+
+	switch cmd {
+	case cleanCmd.FullCommand():
+		logging.Configure(*level, *logFormat, os.Stderr)
+		clean()
+	case fwCmd.FullCommand():
+		logging.Configure(*level, *logFormat, os.Stderr)
+		killSwitchCmd()
+	case regionsCmd.FullCommand():
+		logging.Configure(*level, *logFormat, os.Stderr)
+		listRegions()
+	case ovpnCmd.FullCommand():
+		logging.Configure(*level, *logFormat, os.Stderr)
+		writeOpenVPN()
+	case diffCmd.FullCommand():
+		logging.Configure(*level, *logFormat, os.Stderr)
+		diffSettings()
+	default:
+		logging.Configure(*level, *logFormat, os.Stdout)
+		run()
+	}
+}
+
+// fatalf sends the fatal notification and exits. Without a dispatcher in use
+// one is set up from the notifications of c, which may be nil or a config
+// that failed validation.
+func fatalf(c *config.Config, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if !notify.Active() && c != nil && c.Notify != nil {
+		if d, er := notify.NewDispatcher(c.Notify); er == nil {
+			notify.Use(d)
+		}
+	}
+	notify.Now(config.EventFatal, "transmon: giving up", msg, nil, 10*time.Second)
+	logger.Fatalf("%s", msg)
+}
+
+// clean runs one cleaner pass and prints the decisions.
+func clean() {
+	conf, er := config.Read(*conf)
+	if er != nil {
+		fatalf(conf, "Failed to read config: %v", er)
+	}
+
+	decisions, er := newCleaner(conf).CleanTorrents(conf.Cleaner.Rules, *dryRun)
+	if er != nil {
+		fatalf(conf, "Torrent cleaner failed: %v", er)
+	}
+
+	if *cleanJSON {
+		if er := json.NewEncoder(os.Stdout).Encode(decisions); er != nil {
+			fatalf(conf, "%v", er)
+		}
+		return
+	}
+	for _, d := range decisions {
+		fmt.Println(d)
+	}
+}
+
+// killSwitchCmd installs, removes or prints the kill switch rules once.
+func killSwitchCmd() {
+	conf, er := config.Read(*conf)
+	if er != nil {
+		fatalf(conf, "Failed to read config: %v", er)
+	}
+	if *fwAction == "down" {
+		if er := firewall.Remove(conf); er != nil {
+			fatalf(conf, "%v", er)
+		}
+		return
+	}
+
+	fw, er := firewall.New(conf)
+	if er != nil {
+		fatalf(conf, "%v", er)
+	}
+	if *fwAction == "up" {
+		er = fw.Up()
+	} else {
+		er = fw.Script(os.Stdout)
+	}
+	if er != nil {
+		fatalf(conf, "%v", er)
+	}
+}
+
+// diffSettings prints the transmission.settings that differ from
+// Transmission's settings file, or the running Transmission with --live,
+// and exits 1 when any do.
+func diffSettings() {
+	conf, er := config.Read(*conf)
+	if er != nil {
+		fatalf(conf, "Failed to read config: %v", er)
+	}
+	want := transmission.Args(conf.Transmission.Settings)
+
+	var drift []*transmission.Drift
+	if *diffLive {
+		drift, er = rpcClient(conf).Drift(want)
+	} else {
+		drift, er = settingsFile(conf).Drift(want)
+	}
+	if er != nil {
+		fatalf(conf, "%v", er)
+	}
+	if len(drift) < 1 {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "SETTING\tWANT\tHAVE")
+	for _, d := range drift {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Key, jsonValue(d.Want), jsonValue(d.Have))
+	}
+	w.Flush()
+	os.Exit(1)
+}
+
+func jsonValue(v interface{}) string {
+	if v == nil {
+		return "-"
+	}
+	b, er := json.Marshal(v)
+	if er != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// listRegions prints the PIA regions, from the url given with --refresh or
+// the built in list.
+func listRegions() {
+	regions, er := pia.LoadRegions(*regionsURL, 30*time.Second)
+	if er != nil {
+		fatalf(nil, "%v", er)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tHOST\tPORT FORWARD")
+	for _, r := range regions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%v\n", r.ID, r.Name, r.Host, r.PortForward)
+	}
+	w.Flush()
+}
+
+// writeOpenVPN writes the openvpn config for a region with the PIA
+// credentials from the config file. The region list comes from --refresh or
+// pia.openvpn.regions_url when either is set.
+func writeOpenVPN() {
+	conf, er := config.Read(*conf)
+	if er != nil {
+		fatalf(conf, "Failed to read config: %v", er)
+	}
+	url := *ovpnRefresh
+	if url == "" && conf.PIA.OpenVPN != nil {
+		url = conf.PIA.OpenVPN.RegionsURL
+	}
+	regions, er := pia.LoadRegions(url, 30*time.Second)
+	if er != nil {
+		fatalf(conf, "%v", er)
+	}
+	r, er := pia.FindRegion(regions, *ovpnRegion)
+	if er != nil {
+		fatalf(conf, "%v", er)
+	}
+	ca := *ovpnCA
+	if ca == "" && conf.PIA.OpenVPN != nil {
+		ca = conf.PIA.OpenVPN.CA
+	}
+	if ca == "" {
+		fatalf(conf, "No PIA ca certificate, set --ca or pia.openvpn.ca")
+	}
+
+	o := &pia.OpenVPN{Region: r, Protocol: *ovpnProtocol, Cipher: *ovpnCipher, Device: conf.OpenVPN.Tun, CA: ca}
+	file, er := o.Write(*ovpnDir, conf.PIA.User, conf.PIA.Pass)
+	if er != nil {
+		fatalf(conf, "%v", er)
+	}
+	fmt.Println(file)
+}
+
+func run() {
 	logger.Infof("Starting transmon version %v", version)
+	if *dryRun {
+		logger.Infof("Torrent cleaner is in dry-run mode")
+	}
 
 	c, stop := context.WithCancel(context.Background())
-	conf, er := config.ReadAndWatch(*conf, c)
+	w, er := config.ReadAndWatch(*conf, c)
 	if er != nil {
-		logger.Fatalf("Failed to read config: %v", er)
+		read, _ := config.Read(*conf)
+		fatalf(read, "Failed to read config: %v", er)
 	}
 
-	go workers(conf, c, stop)
+	// Each worker is counted in wg and done once it has cleaned up, the
+	// workers one once Transmission, the VPN and the kill switch are down.
+	var wg sync.WaitGroup
+	spawn := func(fn func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	notifications(w, c, spawn)
+	spawn(func() { workers(w, c, stop) })
+	spawn(func() { cleaner(w, c) })
+	spawn(func() { ingester(w, c) })
 
-	if conf.Cleaner.Enabled {
-		go cleaner(conf, c)
+	if a := w.Config().API; a != nil && a.Listen != "" {
+		if a.Token == "" && !api.Loopback(a.Listen) {
+			logger.Warnf("API on %s has no api.token, anyone who can reach it can restart the VPN", a.Listen)
+		}
+		spawn(func() {
+			if er := api.ListenAndServe(a.Listen, api.New(state, a.Token), c); er != nil {
+				logger.Errorf("API server failed: %v", er)
+			}
+		})
 	}
 
-	sig := make(chan os.Signal, 1)
+	sig := make(chan os.Signal, 2)
 	signal.Notify(sig, syscall.SIGTERM, syscall.SIGINT)
+	<-sig
+	logger.Infof("Received interrupt, shutting down...")
+	stop()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
 	select {
-	case <-sig:
-		logger.Infof("Received interrupt, shutting down...")
-		close(sig)
-		stop()
-		time.Sleep(3 * time.Second)
+	case <-done:
+		logger.Infof("Shutdown complete")
 		os.Exit(0)
+	case <-sig:
+		logger.Warnf("Received second interrupt, exiting without waiting")
+		os.Exit(1)
 	}
 }
@@ -2,10 +2,54 @@ package main
 
 import (
 	"os"
+	"testing"
+	"time"
 
-	"github.com/albertrdixon/gearbox/logger"
+	"github.com/albertrdixon/transmon/logging"
+	"github.com/stretchr/testify/assert"
 )
 
 func init() {
-	logger.Configure("debug", "[transmon] ", os.Stdout)
+	logging.Configure("debug", logging.Text, os.Stdout)
+}
+
+type fakeRenewer struct {
+	interval time.Duration
+}
+
+func (f *fakeRenewer) Name() string                       { return "fake" }
+func (f *fakeRenewer) RequestPort(ip string) (int, error) { return 40000, nil }
+func (f *fakeRenewer) Renew(ip string) (int, error)       { return 40000, nil }
+func (f *fakeRenewer) RenewInterval() time.Duration       { return f.interval }
+
+func TestRenewalFollowsGrantedLifetime(t *testing.T) {
+	is := assert.New(t)
+	f := &fakeRenewer{interval: time.Hour}
+	r := new(renewal)
+	defer r.stop()
+
+	r.reset(f)
+	is.Equal(time.Hour, r.d)
+	select {
+	case <-r.c():
+		t.Fatal("renewal fired before the configured interval")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The gateway granted a shorter lifetime on the last mapping.
+	f.interval = 20 * time.Millisecond
+	r.rearm()
+	is.Equal(20*time.Millisecond, r.d)
+	select {
+	case <-r.c():
+	case <-time.After(time.Second):
+		t.Fatal("renewal did not follow the granted lifetime")
+	}
+}
+
+func TestRenewalNoRenewer(t *testing.T) {
+	is := assert.New(t)
+	r := new(renewal)
+	r.rearm()
+	is.Nil(r.c())
 }
@@ -0,0 +1,268 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	PortUpdates = NewCounterVec("transmon_port_updates_total",
+		"Transmission peer port updates by result.", "result")
+	PortChecks = NewCounterVec("transmon_port_checks_total",
+		"Transmission port checks by result.", "result")
+	// PortOpen has no labels but is a vector so it is only exported once a
+	// port check ran, use PortOpen.With().
+	PortOpen = NewGaugeVec("transmon_port_open",
+		"Whether the last port check found the peer port open.")
+	TunnelChecks = NewCounterVec("transmon_tunnel_checks_total",
+		"Tunnel health checks by result.", "result")
+	Leaks = NewCounter("transmon_leaks_total",
+		"Leak checks that found traffic bypassing the VPN.")
+	VPNBytes = NewGaugeVec("transmon_vpn_bytes",
+		"Bytes through the tunnel since it connected by direction.", "direction")
+	VPNRestarts = NewCounter("transmon_vpn_restarts_total",
+		"Restarts of the VPN and Transmission done by transmon.")
+	Failovers = NewCounter("transmon_failovers_total",
+		"Moves to another VPN server after the current one kept failing.")
+	ProcessRestarts = NewCounterVec("transmon_process_restarts_total",
+		"Restarts of a child process after it exited on its own.", "process")
+	TorrentsRemoved = NewCounterVec("transmon_torrents_removed_total",
+		"Torrents removed by the cleaner by reason.", "reason")
+	TorrentsAdded = NewCounterVec("transmon_torrents_added_total",
+		"Torrents added from watch directories and feeds by source.", "source")
+	PIARequestDuration = NewHistogramVec("transmon_pia_request_duration_seconds",
+		"Latency of requests to the PIA port forwarding api.",
+		[]float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30}, "api")
+)
+
+var registry = struct {
+	sync.Mutex
+	families []*family
+}{}
+
+type metric interface {
+	write(b *bytes.Buffer, name, labels string)
+}
+
+// family is a named metric with zero or more label dimensions.
+type family struct {
+	name, help, kind string
+	labels           []string
+	new              func() metric
+
+	mu       sync.Mutex
+	children map[string]metric
+}
+
+func register(name, help, kind string, fn func() metric, labels ...string) *family {
+	f := &family{
+		name:     name,
+		help:     help,
+		kind:     kind,
+		labels:   labels,
+		new:      fn,
+		children: make(map[string]metric),
+	}
+	registry.Lock()
+	registry.families = append(registry.families, f)
+	registry.Unlock()
+	return f
+}
+
+func (f *family) with(values ...string) metric {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s wants %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	pairs := make([]string, len(values))
+	for i := range values {
+		pairs[i] = fmt.Sprintf("%s=%q", f.labels[i], values[i])
+	}
+	key := strings.Join(pairs, ",")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m, ok := f.children[key]
+	if !ok {
+		m = f.new()
+		f.children[key] = m
+	}
+	return m
+}
+
+func (f *family) write(b *bytes.Buffer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.children) < 1 {
+		return
+	}
+
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+	keys := make([]string, 0, len(f.children))
+	for k := range f.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		f.children[k].write(b, f.name, k)
+	}
+}
+
+type value struct {
+	mu sync.Mutex
+	v  float64
+}
+
+func (v *value) add(f float64) {
+	v.mu.Lock()
+	v.v += f
+	v.mu.Unlock()
+}
+
+func (v *value) set(f float64) {
+	v.mu.Lock()
+	v.v = f
+	v.mu.Unlock()
+}
+
+func (v *value) write(b *bytes.Buffer, name, labels string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	fmt.Fprintf(b, "%s%s %s\n", name, braces(labels), format(v.v))
+}
+
+type Counter struct{ *value }
+
+func (c Counter) Inc()          { c.add(1) }
+func (c Counter) Add(f float64) { c.add(f) }
+
+type Gauge struct{ *value }
+
+func (g Gauge) Set(f float64) { g.set(f) }
+
+func (g Gauge) SetBool(b bool) {
+	if b {
+		g.set(1)
+	} else {
+		g.set(0)
+	}
+}
+
+type CounterVec struct{ f *family }
+
+func NewCounter(name, help string) Counter {
+	return Counter{register(name, help, "counter", newValue).with().(*value)}
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{register(name, help, "counter", newValue, labels...)}
+}
+
+func (c *CounterVec) With(values ...string) Counter {
+	return Counter{c.f.with(values...).(*value)}
+}
+
+func NewGauge(name, help string) Gauge {
+	return Gauge{register(name, help, "gauge", newValue).with().(*value)}
+}
+
+type GaugeVec struct{ f *family }
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{register(name, help, "gauge", newValue, labels...)}
+}
+
+func (g *GaugeVec) With(values ...string) Gauge {
+	return Gauge{g.f.with(values...).(*value)}
+}
+
+type histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func (h *histogram) Observe(f float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, le := range h.buckets {
+		if f <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += f
+}
+
+func (h *histogram) write(b *bytes.Buffer, name, labels string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+	for i, le := range h.buckets {
+		fmt.Fprintf(b, "%s_bucket{%s%sle=%q} %d\n", name, labels, sep, format(le), h.counts[i])
+	}
+	fmt.Fprintf(b, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, sep, h.count)
+	fmt.Fprintf(b, "%s_sum%s %s\n", name, braces(labels), format(h.sum))
+	fmt.Fprintf(b, "%s_count%s %d\n", name, braces(labels), h.count)
+}
+
+type Histogram interface {
+	Observe(float64)
+}
+
+type HistogramVec struct{ f *family }
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	sort.Float64s(buckets)
+	fn := func() metric {
+		return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+	}
+	return &HistogramVec{register(name, help, "histogram", fn, labels...)}
+}
+
+func (h *HistogramVec) With(values ...string) Histogram {
+	return h.f.with(values...).(*histogram)
+}
+
+// Handler serves every registered metric in the prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b bytes.Buffer
+		registry.Lock()
+		for _, f := range registry.families {
+			f.write(&b)
+		}
+		registry.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(b.Bytes())
+	})
+}
+
+func newValue() metric {
+	return new(value)
+}
+
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+func format(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	is := assert.New(t)
+	var (
+		c = NewCounterVec("test_things_total", "Things.", "kind")
+		g = NewGauge("test_open", "Open.")
+		h = NewHistogramVec("test_seconds", "Seconds.", []float64{1, 0.5}, "api")
+	)
+	c.With("a").Inc()
+	c.With("a").Inc()
+	c.With("b").Add(3)
+	g.SetBool(true)
+	h.With("x").Observe(0.2)
+	h.With("x").Observe(0.7)
+	h.With("x").Observe(2)
+
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+	resp, er := http.Get(server.URL)
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	out := string(body)
+
+	is.Contains(out, "# TYPE test_things_total counter\n")
+	is.Contains(out, `test_things_total{kind="a"} 2`+"\n")
+	is.Contains(out, `test_things_total{kind="b"} 3`+"\n")
+	is.Contains(out, "test_open 1\n")
+	is.Contains(out, `test_seconds_bucket{api="x",le="0.5"} 1`+"\n")
+	is.Contains(out, `test_seconds_bucket{api="x",le="1"} 2`+"\n")
+	is.Contains(out, `test_seconds_bucket{api="x",le="+Inf"} 3`+"\n")
+	is.Contains(out, `test_seconds_count{api="x"} 3`+"\n")
+	is.Contains(out, "transmon_vpn_restarts_total 0\n")
+	is.NotContains(out, "transmon_port_updates_total", "label sets without samples are skipped")
+	is.NotContains(out, "transmon_port_open", "port_open is unknown until a port check ran")
+}
@@ -0,0 +1,84 @@
+package natpmp
+
+import (
+	"sync"
+	"time"
+)
+
+const Name = "protonvpn-natpmp"
+
+// Forwarder maps the same port for UDP and TCP on the gateway and keeps the
+// mapping alive, following the gateway when it hands out a new port.
+type Forwarder struct {
+	*Client
+	InternalPort int
+	Lifetime     time.Duration
+
+	mu      sync.Mutex
+	port    int
+	granted time.Duration
+}
+
+func NewForwarder(gateway string, internal int, lifetime, timeout time.Duration) *Forwarder {
+	return &Forwarder{
+		Client:       NewClient(gateway, timeout),
+		InternalPort: internal,
+		Lifetime:     lifetime,
+	}
+}
+
+func (f *Forwarder) Name() string {
+	return Name
+}
+
+func (f *Forwarder) RequestPort(ip string) (int, error) {
+	return f.mapPorts()
+}
+
+func (f *Forwarder) Renew(ip string) (int, error) {
+	return f.mapPorts()
+}
+
+// RenewInterval is half the mapping lifetime so one lost renewal does not
+// let the mapping expire. Once a port is mapped that is the lifetime the
+// gateway granted, which may be shorter than the one asked for.
+func (f *Forwarder) RenewInterval() time.Duration {
+	f.mu.Lock()
+	lifetime := f.granted
+	f.mu.Unlock()
+	if lifetime <= 0 {
+		lifetime = f.Lifetime
+	}
+	if d := lifetime / 2; d > time.Second {
+		return d
+	}
+	return time.Second
+}
+
+func (f *Forwarder) mapPorts() (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	udp, er := f.AddPortMapping("udp", f.InternalPort, f.port, f.Lifetime)
+	if er != nil {
+		return 0, er
+	}
+	tcp, er := f.AddPortMapping("tcp", f.InternalPort, udp.External, f.Lifetime)
+	if er != nil {
+		return 0, er
+	}
+	if tcp.External != udp.External {
+		logger.Warnf("Gateway mapped different ports for udp (%d) and tcp (%d), using tcp", udp.External, tcp.External)
+	}
+	if f.port != 0 && f.port != tcp.External {
+		logger.Infof("Gateway moved the mapped port from %d to %d", f.port, tcp.External)
+	}
+
+	f.port = tcp.External
+	f.granted = tcp.Lifetime
+	if udp.Lifetime < f.granted {
+		f.granted = udp.Lifetime
+	}
+	logger.Debugf("NAT-PMP mapped port %d for %v", f.port, tcp.Lifetime)
+	return f.port, nil
+}
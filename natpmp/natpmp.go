@@ -0,0 +1,147 @@
+package natpmp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/albertrdixon/transmon/logging"
+)
+
+var logger = logging.New("natpmp")
+
+const (
+	Port = 5351
+
+	opExternalAddress = 0
+	opMapUDP          = 1
+	opMapTCP          = 2
+	opResponse        = 128
+
+	initialWait = 250 * time.Millisecond
+	maxAttempts = 9
+)
+
+var resultCodes = map[uint16]string{
+	1: "Unsupported Version",
+	2: "Not Authorized/Refused",
+	3: "Network Failure",
+	4: "Out of resources",
+	5: "Unsupported opcode",
+}
+
+// Client speaks NAT-PMP (RFC 6886) to a single gateway.
+type Client struct {
+	Gateway string
+	Timeout time.Duration
+}
+
+// Mapping is a port mapping granted by the gateway.
+type Mapping struct {
+	Protocol string
+	Internal int
+	External int
+	Lifetime time.Duration
+}
+
+func NewClient(gateway string, timeout time.Duration) *Client {
+	return &Client{Gateway: gateway, Timeout: timeout}
+}
+
+func (c *Client) ExternalAddress() (net.IP, error) {
+	resp, er := c.call([]byte{0, opExternalAddress}, 12)
+	if er != nil {
+		return nil, er
+	}
+	return net.IPv4(resp[8], resp[9], resp[10], resp[11]), nil
+}
+
+// AddPortMapping asks for external to be forwarded to internal. external is
+// only a suggestion, the gateway is free to hand out another port.
+func (c *Client) AddPortMapping(protocol string, internal, external int, lifetime time.Duration) (*Mapping, error) {
+	var op byte
+	switch protocol {
+	case "udp":
+		op = opMapUDP
+	case "tcp":
+		op = opMapTCP
+	default:
+		return nil, fmt.Errorf("Unknown protocol %q", protocol)
+	}
+
+	msg := make([]byte, 12)
+	msg[1] = op
+	binary.BigEndian.PutUint16(msg[4:6], uint16(internal))
+	binary.BigEndian.PutUint16(msg[6:8], uint16(external))
+	binary.BigEndian.PutUint32(msg[8:12], uint32(lifetime/time.Second))
+
+	resp, er := c.call(msg, 16)
+	if er != nil {
+		return nil, er
+	}
+	return &Mapping{
+		Protocol: protocol,
+		Internal: int(binary.BigEndian.Uint16(resp[8:10])),
+		External: int(binary.BigEndian.Uint16(resp[10:12])),
+		Lifetime: time.Duration(binary.BigEndian.Uint32(resp[12:16])) * time.Second,
+	}, nil
+}
+
+// call sends msg and waits for the matching response, resending with the
+// doubling delay from the RFC until Timeout runs out.
+func (c *Client) call(msg []byte, size int) ([]byte, error) {
+	addr := c.Gateway
+	if _, _, er := net.SplitHostPort(addr); er != nil {
+		addr = net.JoinHostPort(addr, fmt.Sprint(Port))
+	}
+	conn, er := net.Dial("udp", addr)
+	if er != nil {
+		return nil, er
+	}
+	defer conn.Close()
+
+	var (
+		deadline = time.Now().Add(c.Timeout)
+		wait     = initialWait
+		buf      = make([]byte, 16)
+	)
+	for i := 0; i < maxAttempts && time.Now().Before(deadline); i++ {
+		logger.Debugf("NAT-PMP request op=%d to %s (attempt %d)", msg[1], addr, i+1)
+		if _, er := conn.Write(msg); er != nil {
+			return nil, er
+		}
+
+		until := time.Now().Add(wait)
+		if until.After(deadline) {
+			until = deadline
+		}
+		conn.SetReadDeadline(until)
+		for {
+			n, er := conn.Read(buf)
+			if er != nil {
+				if ne, ok := er.(net.Error); ok && ne.Timeout() {
+					break
+				}
+				return nil, er
+			}
+			if n < size || buf[0] != 0 || buf[1] != opResponse+msg[1] {
+				continue
+			}
+			if code := binary.BigEndian.Uint16(buf[2:4]); code != 0 {
+				return nil, fmt.Errorf("NAT-PMP error: %s", result(code))
+			}
+			return buf[:size], nil
+		}
+		wait *= 2
+	}
+	return nil, errors.New("NAT-PMP gateway did not respond")
+}
+
+func result(code uint16) string {
+	if s, ok := resultCodes[code]; ok {
+		return s
+	}
+	return fmt.Sprintf("result code %d", code)
+}
@@ -0,0 +1,123 @@
+package natpmp
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// granted is the mapping lifetime in seconds the test gateway hands out, the
+// requested one when 0.
+var granted uint32
+
+// testGateway answers NAT-PMP requests on a local UDP socket. ports holds the
+// external port handed out for each mapping request in turn.
+func testGateway(t *testing.T, ports ...int) (*net.UDPConn, chan []byte) {
+	conn, er := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if er != nil {
+		t.Fatal(er)
+	}
+	reqs := make(chan []byte, 32)
+
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, from, er := conn.ReadFromUDP(buf)
+			if er != nil {
+				return
+			}
+			req := append([]byte(nil), buf[:n]...)
+			reqs <- req
+
+			var resp []byte
+			switch req[1] {
+			case opExternalAddress:
+				resp = make([]byte, 12)
+				copy(resp[8:], net.IPv4(1, 2, 3, 4).To4())
+			case opMapUDP, opMapTCP:
+				resp = make([]byte, 16)
+				copy(resp[8:10], req[4:6])
+				port := ports[0]
+				if len(ports) > 1 && req[1] == opMapTCP {
+					ports = ports[1:]
+				}
+				binary.BigEndian.PutUint16(resp[10:12], uint16(port))
+				copy(resp[12:16], req[8:12])
+				if granted > 0 {
+					binary.BigEndian.PutUint32(resp[12:16], granted)
+				}
+			default:
+				resp = make([]byte, 8)
+				binary.BigEndian.PutUint16(resp[2:4], 5)
+			}
+			resp[1] = opResponse + req[1]
+			conn.WriteToUDP(resp, from)
+		}
+	}()
+	return conn, reqs
+}
+
+func TestExternalAddress(t *testing.T) {
+	is := assert.New(t)
+	gw, _ := testGateway(t, 1)
+	defer gw.Close()
+
+	ip, er := NewClient(gw.LocalAddr().String(), time.Second).ExternalAddress()
+	is.NoError(er)
+	is.Equal("1.2.3.4", ip.String())
+}
+
+func TestForwarderRenew(t *testing.T) {
+	is := assert.New(t)
+	gw, reqs := testGateway(t, 40000, 40123)
+	defer gw.Close()
+
+	f := NewForwarder(gw.LocalAddr().String(), 1, 60*time.Second, time.Second)
+	is.Equal(30*time.Second, f.RenewInterval())
+
+	port, er := f.RequestPort("10.2.0.2")
+	is.NoError(er)
+	is.Equal(40000, port)
+
+	udp, tcp := <-reqs, <-reqs
+	is.Equal(byte(opMapUDP), udp[1])
+	is.Equal(byte(opMapTCP), tcp[1])
+	is.Equal(uint16(1), binary.BigEndian.Uint16(udp[4:6]))
+	is.Equal(uint16(0), binary.BigEndian.Uint16(udp[6:8]))
+	is.Equal(uint16(40000), binary.BigEndian.Uint16(tcp[6:8]))
+	is.Equal(uint32(60), binary.BigEndian.Uint32(tcp[8:12]))
+
+	port, er = f.Renew("10.2.0.2")
+	is.NoError(er)
+	is.Equal(40123, port)
+	udp = <-reqs
+	is.Equal(uint16(40000), binary.BigEndian.Uint16(udp[6:8]), "renewal should ask for the current port")
+}
+
+func TestForwarderGrantedLifetime(t *testing.T) {
+	is := assert.New(t)
+	granted = 20
+	defer func() { granted = 0 }()
+	gw, _ := testGateway(t, 40000)
+	defer gw.Close()
+
+	f := NewForwarder(gw.LocalAddr().String(), 1, 60*time.Second, time.Second)
+	_, er := f.RequestPort("10.2.0.2")
+	is.NoError(er)
+	is.Equal(10*time.Second, f.RenewInterval(), "renew at half of what the gateway granted")
+}
+
+func TestNoGateway(t *testing.T) {
+	is := assert.New(t)
+	conn, er := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	defer conn.Close()
+
+	_, er = NewClient(conn.LocalAddr().String(), 300*time.Millisecond).ExternalAddress()
+	is.Error(er)
+}
@@ -0,0 +1,208 @@
+// Package notify tells people what transmon is doing: port changes, VPN
+// restarts, finished and cleaned torrents and giving up.
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/logging"
+	"golang.org/x/net/context"
+)
+
+var logger = logging.New("notify")
+
+// Event is something worth telling people about. Message is used when no
+// template is configured, templates can use every field.
+type Event struct {
+	Type    string            `json:"event"`
+	Time    time.Time         `json:"time"`
+	Title   string            `json:"title"`
+	Message string            `json:"message"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// Notifier delivers a rendered message to one place.
+type Notifier interface {
+	Name() string
+	Notify(e *Event) error
+}
+
+// New is an event of type t at the current time.
+func New(t, title, message string, fields map[string]string) *Event {
+	return &Event{Type: t, Time: time.Now(), Title: title, Message: message, Fields: fields}
+}
+
+type sink struct {
+	Notifier
+	conf     *config.Sink
+	template *template.Template
+}
+
+// Dispatcher sends events to every sink that wants them. A nil Dispatcher
+// drops everything.
+type Dispatcher struct {
+	sinks     []*sink
+	templates map[string]*template.Template
+	queue     chan *Event
+}
+
+// NewDispatcher builds the sinks of c, it returns nil when there are none.
+func NewDispatcher(c *config.Notifications) (*Dispatcher, error) {
+	if len(c.Sinks) < 1 {
+		return nil, nil
+	}
+	d := &Dispatcher{templates: make(map[string]*template.Template), queue: make(chan *Event, 64)}
+	for event, text := range c.Templates {
+		t, er := template.New(event).Parse(text)
+		if er != nil {
+			return nil, er
+		}
+		d.templates[event] = t
+	}
+	for _, s := range c.Sinks {
+		n, er := newNotifier(s)
+		if er != nil {
+			return nil, er
+		}
+		sk := &sink{Notifier: n, conf: s}
+		if s.Template != "" {
+			if sk.template, er = template.New(s.Type).Parse(s.Template); er != nil {
+				return nil, er
+			}
+		}
+		d.sinks = append(d.sinks, sk)
+	}
+	return d, nil
+}
+
+func newNotifier(s *config.Sink) (Notifier, error) {
+	switch s.Type {
+	case config.SinkWebhook:
+		return &Webhook{URL: s.URL, Headers: s.Headers}, nil
+	case config.SinkPushover:
+		return &Pushover{Token: s.Token, User: s.User, Priority: s.Priority, URL: s.URL}, nil
+	case config.SinkGotify:
+		return &Gotify{URL: s.URL, Token: s.Token, Priority: s.Priority}, nil
+	case config.SinkSMTP:
+		return &SMTP{Host: s.Host, Username: s.Username, Password: s.Password, From: s.From, To: s.To}, nil
+	}
+	return nil, fmt.Errorf("Unknown notification sink %q", s.Type)
+}
+
+// Send queues e for delivery without waiting on any sink. Events are
+// dropped when the queue is full.
+func (d *Dispatcher) Send(e *Event) {
+	if d == nil {
+		return
+	}
+	select {
+	case d.queue <- e:
+	default:
+		logger.Warnf("Notification queue is full, dropping %s", e.Type)
+	}
+}
+
+// Run delivers queued events until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-d.queue:
+			d.deliver(e)
+		}
+	}
+}
+
+// deliver sends e to every sink that wants it and waits for all of them.
+func (d *Dispatcher) deliver(e *Event) {
+	var wg sync.WaitGroup
+	for _, s := range d.sinks {
+		if !s.conf.Wants(e.Type) {
+			continue
+		}
+		msg := *e
+		msg.Message = d.render(s, e)
+		wg.Add(1)
+		go func(s *sink, msg *Event) {
+			defer wg.Done()
+			if er := s.Notify(msg); er != nil {
+				logger.Warnf("Failed to send %s notification to %s: %v", msg.Type, s.Name(), er)
+			}
+		}(s, &msg)
+	}
+	wg.Wait()
+}
+
+// render is the message for e from the sink's template, the event's
+// template or the event itself, in that order.
+func (d *Dispatcher) render(s *sink, e *Event) string {
+	t := s.template
+	if t == nil {
+		t = d.templates[e.Type]
+	}
+	if t == nil {
+		return e.Message
+	}
+	var b bytes.Buffer
+	if er := t.Execute(&b, e); er != nil {
+		logger.Warnf("Failed to render %s notification for %s: %v", e.Type, s.Name(), er)
+		return e.Message
+	}
+	return b.String()
+}
+
+var (
+	mu      sync.RWMutex
+	current *Dispatcher
+)
+
+// Use makes d the dispatcher Send and Now go through.
+func Use(d *Dispatcher) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = d
+}
+
+func get() *Dispatcher {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Active reports whether a dispatcher is in use.
+func Active() bool {
+	return get() != nil
+}
+
+// Send queues an event on the dispatcher in use.
+func Send(t, title, message string, fields map[string]string) {
+	get().Send(New(t, title, message, fields))
+}
+
+// Now delivers an event on the dispatcher in use and waits up to timeout
+// for it, for when transmon is about to exit.
+func Now(t, title, message string, fields map[string]string, timeout time.Duration) {
+	d := get()
+	if d == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		d.deliver(New(t, title, message, fields))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logger.Warnf("Gave up sending %s notification after %v", t, timeout)
+	}
+}
@@ -0,0 +1,121 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+type request struct {
+	path, body string
+	header     http.Header
+}
+
+func testServer() (*httptest.Server, <-chan *request) {
+	ch := make(chan *request, 10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		ch <- &request{path: r.URL.Path, body: string(b), header: r.Header}
+	}))
+	return s, ch
+}
+
+func TestDispatcher(t *testing.T) {
+	is := assert.New(t)
+	s, requests := testServer()
+	defer s.Close()
+
+	d, er := NewDispatcher(&config.Notifications{
+		Templates: map[string]string{config.EventPortChanged: "port {{.Fields.port}}"},
+		Sinks: []*config.Sink{
+			{Type: config.SinkWebhook, URL: s.URL + "/hook", Headers: map[string]string{"X-Token": "secret"}},
+			{Type: config.SinkPushover, URL: s.URL + "/pushover", Token: "app", User: "me", Events: []string{config.EventFatal}},
+			{Type: config.SinkGotify, URL: s.URL + "/", Token: "key", Events: []string{config.EventPortChanged}, Template: "gotify {{.Fields.port}} {{.Type}}"},
+		},
+	})
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	d.Send(New(config.EventPortChanged, "port changed", "untemplated", map[string]string{"port": "51413"}))
+	got := map[string]*request{}
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-requests:
+			got[r.path] = r
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for notifications")
+		}
+	}
+
+	if hook := got["/hook"]; is.NotNil(hook) {
+		var e Event
+		is.NoError(json.Unmarshal([]byte(hook.body), &e))
+		is.Equal(config.EventPortChanged, e.Type)
+		is.Equal("port 51413", e.Message)
+		is.Equal("secret", hook.header.Get("X-Token"))
+	}
+	if gotify := got["/message"]; is.NotNil(gotify) {
+		is.Contains(gotify.body, `"message":"gotify 51413 port_changed"`)
+		is.Equal("key", gotify.header.Get("X-Gotify-Key"))
+	}
+	is.Nil(got["/pushover"], "pushover only wants fatal events")
+
+	is.False(Active())
+	Use(d)
+	defer Use(nil)
+	is.True(Active())
+	Now(config.EventFatal, "giving up", "boom", nil, 5*time.Second)
+	got = map[string]*request{}
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-requests:
+			got[r.path] = r
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the fatal notification")
+		}
+	}
+	if push := got["/pushover"]; is.NotNil(push) {
+		is.Contains(push.body, "message=boom")
+		is.Contains(push.body, "token=app")
+	}
+
+	var nilDispatcher *Dispatcher
+	nilDispatcher.Send(New(config.EventFatal, "", "", nil))
+	_, er = NewDispatcher(&config.Notifications{Sinks: []*config.Sink{{Type: "carrier-pigeon"}}})
+	is.Error(er)
+}
+
+func TestSMTP(t *testing.T) {
+	is := assert.New(t)
+	var (
+		mu   sync.Mutex
+		sent string
+	)
+	s := &SMTP{Host: "mail.example.com:587", Username: "me", Password: "pw", From: "transmon@example.com", To: []string{"me@example.com"}}
+	s.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		is.Equal("mail.example.com:587", addr)
+		is.NotNil(a)
+		sent = string(msg)
+		return nil
+	}
+	is.NoError(s.Notify(New(config.EventVPNRestarted, "vpn restarted", "line one\nline two", nil)))
+	is.Contains(sent, "Subject: vpn restarted\r\n")
+	is.Contains(sent, "To: me@example.com\r\n")
+	is.True(strings.HasSuffix(sent, "\r\n\r\nline one\r\nline two\r\n"))
+}
@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const PushoverURL = "https://api.pushover.net/1/messages.json"
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+// Webhook POSTs the event as json.
+type Webhook struct {
+	URL     string
+	Headers map[string]string
+}
+
+func (w *Webhook) Name() string { return "webhook" }
+
+func (w *Webhook) Notify(e *Event) error {
+	body, er := json.Marshal(e)
+	if er != nil {
+		return er
+	}
+	req, er := http.NewRequest("POST", w.URL, bytes.NewReader(body))
+	if er != nil {
+		return er
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+	return do(req)
+}
+
+// Pushover sends to the pushover messages api, URL overrides its address.
+type Pushover struct {
+	Token, User, URL string
+	Priority         int
+}
+
+func (p *Pushover) Name() string { return "pushover" }
+
+func (p *Pushover) Notify(e *Event) error {
+	u := p.URL
+	if u == "" {
+		u = PushoverURL
+	}
+	form := url.Values{
+		"token":     {p.Token},
+		"user":      {p.User},
+		"title":     {e.Title},
+		"message":   {e.Message},
+		"timestamp": {strconv.FormatInt(e.Time.Unix(), 10)},
+		"priority":  {strconv.Itoa(p.Priority)},
+	}
+	req, er := http.NewRequest("POST", u, strings.NewReader(form.Encode()))
+	if er != nil {
+		return er
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return do(req)
+}
+
+// Gotify posts to the message endpoint of a gotify server.
+type Gotify struct {
+	URL, Token string
+	Priority   int
+}
+
+func (g *Gotify) Name() string { return "gotify" }
+
+func (g *Gotify) Notify(e *Event) error {
+	body, er := json.Marshal(map[string]interface{}{
+		"title":    e.Title,
+		"message":  e.Message,
+		"priority": g.Priority,
+	})
+	if er != nil {
+		return er
+	}
+	req, er := http.NewRequest("POST", strings.TrimSuffix(g.URL, "/")+"/message", bytes.NewReader(body))
+	if er != nil {
+		return er
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.Token)
+	return do(req)
+}
+
+// SMTP mails the event, with plain auth when Username is set.
+type SMTP struct {
+	Host, Username, Password, From string
+	To                             []string
+	send                           func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func (s *SMTP) Name() string { return "smtp" }
+
+func (s *SMTP) Notify(e *Event) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host := s.Host
+		if i := strings.LastIndex(host, ":"); i > -1 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	send := s.send
+	if send == nil {
+		send = smtp.SendMail
+	}
+	return send(s.Host, auth, s.From, s.To, s.message(e))
+}
+
+func (s *SMTP) message(e *Event) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", e.Title)
+	fmt.Fprintf(&b, "Date: %s\r\n", e.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.Replace(e.Message, "\n", "\r\n", -1))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
+
+func do(req *http.Request) error {
+	resp, er := client.Do(req)
+	if er != nil {
+		return er
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s", req.Method, req.URL, resp.Status)
+	}
+	return nil
+}
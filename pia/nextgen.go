@@ -0,0 +1,317 @@
+package pia
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	ur "net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/albertrdixon/transmon/atomicfile"
+	"github.com/albertrdixon/transmon/logging"
+	"github.com/albertrdixon/transmon/metrics"
+)
+
+const (
+	DefaultTokenURL = `https://www.privateinternetaccess.com/api/client/v2/token`
+	BindInterval    = 15 * time.Minute
+
+	gatewayPort  = 19999
+	expiryMargin = 24 * time.Hour
+)
+
+var ErrExpired = errors.New("Port forwarding signature expired")
+
+// NextGen talks to the port forwarding api running on PIA's next generation
+// gateways. A token is traded for a signed payload which holds the port, the
+// payload then has to be bound every few minutes to keep the port open.
+type NextGen struct {
+	User, Pass string
+	TokenURL   string
+	Gateway    string
+	StateFile  string
+
+	client, tokenClient *http.Client
+	mu                  sync.Mutex
+	sig                 *Signature
+}
+
+// Signature is the part of the getSignature response worth keeping around,
+// it stays valid for weeks and survives gateway reconnects.
+type Signature struct {
+	Gateway   string    `json:"gateway"`
+	Payload   string    `json:"payload"`
+	Signature string    `json:"signature"`
+	Port      int       `json:"port"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (s *Signature) expired() bool {
+	return s == nil || time.Now().Add(expiryMargin).After(s.ExpiresAt)
+}
+
+// NewNextGen builds a next gen client. hostname is the common name of the
+// gateway certificate and ca the PIA certificate authority used to verify it,
+// gateway may be left empty to derive it from the tunnel address. The token
+// endpoint is verified against the system roots.
+func NewNextGen(user, pass, hostname, gateway, ca, stateFile string) (*NextGen, error) {
+	if ca == "" {
+		return nil, errors.New("PIA next gen needs the PIA certificate authority to verify the gateway")
+	}
+	pem, er := ioutil.ReadFile(ca)
+	if er != nil {
+		return nil, er
+	}
+	tc := &tls.Config{ServerName: hostname, RootCAs: x509.NewCertPool()}
+	if !tc.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("No certificates found in %q", ca)
+	}
+
+	n := &NextGen{
+		User:      user,
+		Pass:      pass,
+		TokenURL:  DefaultTokenURL,
+		Gateway:   gateway,
+		StateFile: stateFile,
+		client: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tc},
+		},
+		tokenClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	if er := n.load(); er != nil {
+		logger.Warnf("Failed to load PIA port forwarding state: %v", er)
+	}
+	return n, nil
+}
+
+// RequestPort returns the forwarded port for the tunnel bound to ip, asking
+// the gateway for a new signature only when the saved one is unusable.
+func (n *NextGen) RequestPort(ip string) (int, error) {
+	gw, er := n.gateway(ip)
+	if er != nil {
+		return 0, er
+	}
+
+	n.mu.Lock()
+	sig := n.sig
+	n.mu.Unlock()
+
+	if sig.expired() || sig.Gateway != gw {
+		logger.Debugf("Requesting new port forwarding signature from %s", gw)
+		if sig, er = n.signature(gw); er != nil {
+			return 0, er
+		}
+		n.mu.Lock()
+		n.sig = sig
+		n.mu.Unlock()
+		if er := n.save(); er != nil {
+			logger.Warnf("Failed to save PIA port forwarding state: %v", er)
+		}
+	}
+
+	if er := n.Bind(); er != nil {
+		return 0, er
+	}
+	logger.Event("pia_port_signed", logging.Fields{"port": sig.Port, "expires": sig.ExpiresAt}).Debugf("PIA port %d expires at %v", sig.Port, sig.ExpiresAt)
+	return sig.Port, nil
+}
+
+// Bind keeps the port assigned to us, it has to be called at least every
+// BindInterval.
+func (n *NextGen) Bind() error {
+	n.mu.Lock()
+	sig := n.sig
+	n.mu.Unlock()
+
+	if sig == nil || time.Now().After(sig.ExpiresAt) {
+		return ErrExpired
+	}
+
+	values := ur.Values{}
+	values.Add("payload", sig.Payload)
+	values.Add("signature", sig.Signature)
+
+	resp := new(gatewayResponse)
+	if er := n.get(sig.Gateway, "bindPort", values, resp); er != nil {
+		return er
+	}
+	logger.Event("pia_port_bound", logging.Fields{"port": sig.Port}).Debugf("Bound PIA port %d: %s", sig.Port, resp.Message)
+	return nil
+}
+
+// Expires returns when the current port assignment runs out.
+func (n *NextGen) Expires() time.Time {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.sig == nil {
+		return time.Time{}
+	}
+	return n.sig.ExpiresAt
+}
+
+func (n *NextGen) signature(gw string) (*Signature, error) {
+	token, er := n.token()
+	if er != nil {
+		return nil, er
+	}
+
+	values := ur.Values{}
+	values.Add("token", token)
+	resp := new(gatewayResponse)
+	if er := n.get(gw, "getSignature", values, resp); er != nil {
+		return nil, er
+	}
+
+	raw, er := base64.StdEncoding.DecodeString(resp.Payload)
+	if er != nil {
+		return nil, er
+	}
+	p := new(payload)
+	if er := json.Unmarshal(raw, p); er != nil {
+		return nil, er
+	}
+	return &Signature{
+		Gateway:   gw,
+		Payload:   resp.Payload,
+		Signature: resp.Signature,
+		Port:      p.Port,
+		ExpiresAt: p.ExpiresAt,
+	}, nil
+}
+
+func (n *NextGen) token() (string, error) {
+	values := ur.Values{}
+	values.Add("username", n.User)
+	values.Add("password", n.Pass)
+
+	logger.Debugf("POST %v", n.TokenURL)
+	start := time.Now()
+	resp, er := n.tokenClient.PostForm(n.TokenURL, values)
+	took := time.Since(start)
+	metrics.PIARequestDuration.With("token").Observe(took.Seconds())
+	logger.Event("pia_request", logging.Fields{"api": "token", "duration": took}).Debugf("PIA token request took %v", took)
+	if er != nil {
+		return "", er
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Token request failed: %s", resp.Status)
+	}
+
+	t := new(tokenResponse)
+	if er := json.NewDecoder(resp.Body).Decode(t); er != nil {
+		return "", er
+	}
+	if t.Token == "" {
+		return "", errors.New("Token response did not contain a token")
+	}
+	return t.Token, nil
+}
+
+func (n *NextGen) get(gw, method string, values ur.Values, out *gatewayResponse) error {
+	host := gw
+	if _, _, er := net.SplitHostPort(gw); er != nil {
+		host = net.JoinHostPort(gw, fmt.Sprint(gatewayPort))
+	}
+	logger.Debugf("GET https://%s/%s", host, method)
+	start := time.Now()
+	resp, er := n.client.Get(fmt.Sprintf("https://%s/%s?%s", host, method, values.Encode()))
+	took := time.Since(start)
+	metrics.PIARequestDuration.With(method).Observe(took.Seconds())
+	logger.Event("pia_request", logging.Fields{"api": method, "gateway": host, "duration": took}).Debugf("PIA %s request took %v", method, took)
+	if er != nil {
+		return er
+	}
+	defer resp.Body.Close()
+
+	if er := json.NewDecoder(resp.Body).Decode(out); er != nil {
+		return er
+	}
+	if out.Status != "OK" {
+		return fmt.Errorf("%s failed: %s %s", method, out.Status, out.Message)
+	}
+	return nil
+}
+
+// gateway returns the configured gateway (host or host:port), or the first address of the tunnel
+// network which is where PIA runs the api.
+func (n *NextGen) gateway(ip string) (string, error) {
+	if n.Gateway != "" {
+		return n.Gateway, nil
+	}
+	addr := net.ParseIP(ip).To4()
+	if addr == nil {
+		return "", fmt.Errorf("Cannot derive gateway from %q", ip)
+	}
+	return net.IPv4(addr[0], addr[1], addr[2], 1).String(), nil
+}
+
+func (n *NextGen) load() error {
+	if n.StateFile == "" {
+		return nil
+	}
+	data, er := ioutil.ReadFile(n.StateFile)
+	if os.IsNotExist(er) {
+		return nil
+	} else if er != nil {
+		return er
+	}
+
+	sig := new(Signature)
+	if er := json.Unmarshal(data, sig); er != nil {
+		return er
+	}
+	n.sig = sig
+	return nil
+}
+
+func (n *NextGen) save() error {
+	if n.StateFile == "" {
+		return nil
+	}
+	n.mu.Lock()
+	data, er := json.Marshal(n.sig)
+	n.mu.Unlock()
+	if er != nil {
+		return er
+	}
+	return atomicfile.Write(n.StateFile, data)
+}
+
+func (n *NextGen) Name() string {
+	return "pia-nextgen"
+}
+
+// Renew binds the current signature again, fetching a fresh one first when
+// it is about to run out or the gateway moved.
+func (n *NextGen) Renew(ip string) (int, error) {
+	gw, er := n.gateway(ip)
+	if er != nil {
+		return 0, er
+	}
+
+	n.mu.Lock()
+	sig := n.sig
+	n.mu.Unlock()
+	if sig.expired() || sig.Gateway != gw {
+		return n.RequestPort(ip)
+	}
+
+	if er := n.Bind(); er != nil {
+		return 0, er
+	}
+	return sig.Port, nil
+}
+
+func (n *NextGen) RenewInterval() time.Duration {
+	return BindInterval
+}
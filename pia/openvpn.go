@@ -0,0 +1,111 @@
+package pia
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// OpenVPN describes a client config for one region. CA is the path of the
+// PIA certificate authority, it is inlined into the config.
+type OpenVPN struct {
+	Region   *Region
+	Protocol string
+	Cipher   string
+	Device   string
+	CA       string
+}
+
+const (
+	AES128 = "aes-128-cbc"
+	AES256 = "aes-256-cbc"
+)
+
+// ports are the gateway ports for each protocol and cipher.
+var ports = map[string]map[string]int{
+	"udp": {AES128: 1198, AES256: 1197},
+	"tcp": {AES128: 502, AES256: 501},
+}
+
+var ovpn = template.Must(template.New("ovpn").Parse(`client
+dev {{.Device}}
+proto {{.Protocol}}
+remote {{.Host}} {{.Port}}
+resolv-retry infinite
+nobind
+persist-key
+persist-tun
+cipher {{.Cipher}}
+auth {{.Auth}}
+tls-client
+remote-cert-tls server
+auth-user-pass {{.AuthFile}}
+reneg-sec 0
+disable-occ
+verb 1
+<ca>
+{{.CA}}
+</ca>
+`))
+
+// Port is the gateway port for the protocol and cipher.
+func (o *OpenVPN) Port() (int, error) {
+	p, ok := ports[o.Protocol][o.Cipher]
+	if !ok {
+		return 0, fmt.Errorf("No PIA port for %s with %s", o.Protocol, o.Cipher)
+	}
+	return p, nil
+}
+
+// Files are the paths Write uses in dir.
+func Files(dir string) (conf, auth string) {
+	return filepath.Join(dir, "pia.ovpn"), filepath.Join(dir, "auth.txt")
+}
+
+// Write puts the config and an auth-user-pass file holding user and pass
+// into dir and returns the path of the config.
+func (o *OpenVPN) Write(dir, user, pass string) (string, error) {
+	port, er := o.Port()
+	if er != nil {
+		return "", er
+	}
+	ca, er := ioutil.ReadFile(o.CA)
+	if er != nil {
+		return "", er
+	}
+	if !bytes.Contains(ca, []byte("BEGIN CERTIFICATE")) {
+		return "", fmt.Errorf("%s is not a PEM certificate", o.CA)
+	}
+
+	conf, auth := Files(dir)
+	if er := os.MkdirAll(dir, 0700); er != nil {
+		return "", er
+	}
+	if er := ioutil.WriteFile(auth, []byte(user+"\n"+pass+"\n"), 0600); er != nil {
+		return "", er
+	}
+
+	authAlg := "sha1"
+	if o.Cipher == AES256 {
+		authAlg = "sha256"
+	}
+	var b bytes.Buffer
+	er = ovpn.Execute(&b, map[string]interface{}{
+		"Device":   o.Device,
+		"Protocol": o.Protocol,
+		"Host":     o.Region.Host,
+		"Port":     port,
+		"Cipher":   o.Cipher,
+		"Auth":     authAlg,
+		"AuthFile": auth,
+		"CA":       strings.TrimSpace(string(ca)),
+	})
+	if er != nil {
+		return "", er
+	}
+	return conf, ioutil.WriteFile(conf, b.Bytes(), 0600)
+}
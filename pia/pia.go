@@ -4,11 +4,15 @@ import (
 	"encoding/json"
 	"net/http"
 	ur "net/url"
+	"time"
 
-	"github.com/albertrdixon/gearbox/logger"
 	"github.com/albertrdixon/gearbox/url"
+	"github.com/albertrdixon/transmon/logging"
+	"github.com/albertrdixon/transmon/metrics"
 )
 
+var logger = logging.New("pia")
+
 var endpoint string
 
 const defaultEndpoint = `https://www.privateinternetaccess.com/vpninfo/port_forward_assignment`
@@ -36,7 +40,11 @@ func RequestPort(ip, user, pass, id string) (int, error) {
 
 	ep := GetPortForwardEndpoint().String()
 	logger.Debugf("POST %v", ep)
+	start := time.Now()
 	resp, er := http.PostForm(ep, values)
+	took := time.Since(start)
+	metrics.PIARequestDuration.With("legacy").Observe(took.Seconds())
+	logger.Event("pia_request", logging.Fields{"api": "legacy", "duration": took}).Debugf("PIA legacy request took %v", took)
 	if er != nil {
 		return 0, er
 	}
@@ -46,3 +54,16 @@ func RequestPort(ip, user, pass, id string) (int, error) {
 	er = json.NewDecoder(resp.Body).Decode(port)
 	return port.Port, er
 }
+
+// Legacy forwards a port with the retired vpninfo/port_forward_assignment api.
+type Legacy struct {
+	User, Pass, ClientID string
+}
+
+func (l *Legacy) Name() string {
+	return "pia"
+}
+
+func (l *Legacy) RequestPort(ip string) (int, error) {
+	return RequestPort(ip, l.User, l.Pass, l.ClientID)
+}
@@ -1,10 +1,18 @@
 package pia
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/albertrdixon/gearbox/url"
 	"github.com/satori/go.uuid"
@@ -41,3 +49,167 @@ func TestRequestPort(t *testing.T) {
 	is.NoError(er)
 	is.Equal(1234, port)
 }
+
+func TestNextGenRequestPort(t *testing.T) {
+	is := assert.New(t)
+	expires := time.Now().Add(60 * 24 * time.Hour).UTC().Truncate(time.Second)
+	p, _ := json.Marshal(payload{Token: "tok", Port: 4567, ExpiresAt: expires})
+	encoded := base64.StdEncoding.EncodeToString(p)
+
+	tokens := testServer("/token", `{"token":"tok"}`)
+	defer tokens.Close()
+
+	binds := 0
+	m := web.New()
+	m.Get("/getSignature", func(w http.ResponseWriter, r *http.Request) {
+		is.Equal("tok", r.URL.Query().Get("token"))
+		fmt.Fprintf(w, `{"status":"OK","payload":%q,"signature":"sig"}`, encoded)
+	})
+	m.Get("/bindPort", func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(encoded, r.URL.Query().Get("payload"))
+		is.Equal("sig", r.URL.Query().Get("signature"))
+		binds++
+		fmt.Fprint(w, `{"status":"OK","message":"port scheduled for add"}`)
+	})
+	gw := httptest.NewTLSServer(m)
+	defer gw.Close()
+
+	dir, er := ioutil.TempDir("", "transmon-pia")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "state.json")
+	ca := filepath.Join(dir, "ca.crt")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: gw.TLS.Certificates[0].Certificate[0]})
+	if !is.NoError(ioutil.WriteFile(ca, cert, 0600)) {
+		t.FailNow()
+	}
+
+	_, er = NewNextGen("user", "pass", "example.com", "", "", state)
+	is.Error(er, "the gateway is not used without a ca")
+
+	wrong, er := NewNextGen("user", "pass", "wrong.example", strings.TrimPrefix(gw.URL, "https://"), ca, state+".wrong")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	wrong.TokenURL = tokens.URL + "/token"
+	_, er = wrong.RequestPort("10.1.2.3")
+	is.Error(er, "gateway certificate is checked against hostname")
+
+	n, er := NewNextGen("user", "pass", "example.com", strings.TrimPrefix(gw.URL, "https://"), ca, state)
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	n.TokenURL = tokens.URL + "/token"
+
+	port, er := n.RequestPort("10.1.2.3")
+	is.NoError(er)
+	is.Equal(4567, port)
+	is.Equal(1, binds)
+	is.True(expires.Equal(n.Expires()))
+
+	n2, er := NewNextGen("user", "pass", "example.com", n.Gateway, ca, state)
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	n2.TokenURL = "http://127.0.0.1:1/unreachable"
+	port, er = n2.RequestPort("10.1.2.3")
+	is.NoError(er, "saved signature should be reused without a new token")
+	is.Equal(4567, port)
+	is.Equal(2, binds)
+}
+
+func TestNextGenGateway(t *testing.T) {
+	is := assert.New(t)
+	n := &NextGen{}
+	gw, er := n.gateway("10.13.10.6")
+	is.NoError(er)
+	is.Equal("10.13.10.1", gw)
+
+	_, er = n.gateway("nope")
+	is.Error(er)
+}
+
+func TestRegions(t *testing.T) {
+	is := assert.New(t)
+	r, er := FindRegion(Regions, "CA Toronto")
+	is.NoError(er)
+	is.Equal("ca-toronto.privateinternetaccess.com", r.Host)
+	_, er = FindRegion(Regions, "atlantis")
+	is.Error(er)
+
+	vpninfo := `{"nl": {"name": "Netherlands", "dns": "nl.privateinternetaccess.com", "port_forward": true}, "info": {"vpn_ports": {}}}`
+	regions := `{"regions": [{"id": "swiss", "name": "Switzerland", "dns": "swiss.example.com", "port_forward": true}]}
+signature`
+	for body, host := range map[string]string{vpninfo: "nl.privateinternetaccess.com", regions: "swiss.example.com"} {
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		}))
+		rs, er := FetchRegions(s.URL, time.Second)
+		s.Close()
+		if is.NoError(er) && is.Len(rs, 1) {
+			is.Equal(host, rs[0].Host)
+			is.True(rs[0].PortForward)
+		}
+	}
+
+	fetches := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		fmt.Fprint(w, regions)
+	}))
+	defer s.Close()
+	for i := 0; i < 3; i++ {
+		rs, er := LoadRegions(s.URL, time.Second)
+		if is.NoError(er) && is.Len(rs, 1) {
+			is.Equal("swiss", rs[0].ID)
+		}
+	}
+	is.Equal(1, fetches, "the region list is only fetched once")
+
+	rs, er := LoadRegions("http://127.0.0.1:1/regions", time.Second)
+	is.Error(er)
+	is.Equal(Regions, rs, "the built in list is used when the fetch fails")
+	rs, er = LoadRegions("", time.Second)
+	is.NoError(er)
+	is.Equal(Regions, rs)
+}
+
+func TestWriteOpenVPN(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "pia")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	ca := filepath.Join(dir, "ca.crt")
+	ioutil.WriteFile(ca, []byte("-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"), 0644)
+	o := &OpenVPN{Region: Regions[0], Protocol: "tcp", Cipher: AES256, Device: "tun0", CA: ca}
+	conf, er := o.Write(filepath.Join(dir, "out"), "user", "pass")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+
+	b, _ := ioutil.ReadFile(conf)
+	for _, line := range []string{"remote " + Regions[0].Host + " 501", "proto tcp", "cipher aes-256-cbc", "auth sha256", "BEGIN CERTIFICATE"} {
+		is.Contains(string(b), line)
+	}
+	_, auth := Files(filepath.Join(dir, "out"))
+	fi, er := os.Stat(auth)
+	if is.NoError(er) {
+		is.Equal(os.FileMode(0600), fi.Mode().Perm())
+	}
+
+	o.CA = conf
+	_, er = o.Write(dir, "user", "pass")
+	is.NoError(er, "config holds a certificate")
+	ioutil.WriteFile(ca, []byte("nope"), 0644)
+	o.CA = ca
+	_, er = o.Write(dir, "user", "pass")
+	is.Error(er)
+	o.CA, o.Protocol = conf, "sctp"
+	_, er = o.Write(dir, "user", "pass")
+	is.Error(er)
+}
@@ -0,0 +1,173 @@
+package pia
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// regionsTTL is how long a fetched region list is used before LoadRegions
+// fetches it again.
+const regionsTTL = time.Hour
+
+var fetched = struct {
+	sync.Mutex
+	url     string
+	at      time.Time
+	regions []*Region
+}{}
+
+// Region is a PIA OpenVPN gateway.
+type Region struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Host        string `json:"host"`
+	PortForward bool   `json:"port_forward"`
+}
+
+// Regions is the built in region list, FetchRegions gets a fresh one.
+var Regions = []*Region{
+	{"au_melbourne", "AU Melbourne", "au-melbourne.privateinternetaccess.com", false},
+	{"au_sydney", "AU Sydney", "au-sydney.privateinternetaccess.com", false},
+	{"austria", "Austria", "austria.privateinternetaccess.com", false},
+	{"belgium", "Belgium", "belgium.privateinternetaccess.com", false},
+	{"brazil", "Brazil", "brazil.privateinternetaccess.com", false},
+	{"ca_montreal", "CA Montreal", "ca-montreal.privateinternetaccess.com", true},
+	{"ca_toronto", "CA Toronto", "ca-toronto.privateinternetaccess.com", true},
+	{"ca_vancouver", "CA Vancouver", "ca-vancouver.privateinternetaccess.com", true},
+	{"czech", "Czech Republic", "czech.privateinternetaccess.com", true},
+	{"de_berlin", "DE Berlin", "de-berlin.privateinternetaccess.com", true},
+	{"de_frankfurt", "DE Frankfurt", "de-frankfurt.privateinternetaccess.com", true},
+	{"denmark", "Denmark", "denmark.privateinternetaccess.com", false},
+	{"fi", "Finland", "fi.privateinternetaccess.com", false},
+	{"france", "France", "france.privateinternetaccess.com", true},
+	{"hk", "Hong Kong", "hk.privateinternetaccess.com", false},
+	{"in", "India", "in.privateinternetaccess.com", false},
+	{"ireland", "Ireland", "ireland.privateinternetaccess.com", false},
+	{"israel", "Israel", "israel.privateinternetaccess.com", true},
+	{"italy", "Italy", "italy.privateinternetaccess.com", false},
+	{"japan", "Japan", "japan.privateinternetaccess.com", false},
+	{"mexico", "Mexico", "mexico.privateinternetaccess.com", false},
+	{"nl", "Netherlands", "nl.privateinternetaccess.com", true},
+	{"nz", "New Zealand", "nz.privateinternetaccess.com", false},
+	{"no", "Norway", "no.privateinternetaccess.com", false},
+	{"poland", "Poland", "poland.privateinternetaccess.com", false},
+	{"ro", "Romania", "ro.privateinternetaccess.com", true},
+	{"sg", "Singapore", "sg.privateinternetaccess.com", false},
+	{"spain", "Spain", "spain.privateinternetaccess.com", true},
+	{"sweden", "Sweden", "sweden.privateinternetaccess.com", true},
+	{"swiss", "Switzerland", "swiss.privateinternetaccess.com", true},
+	{"uk_london", "UK London", "uk-london.privateinternetaccess.com", false},
+	{"uk_manchester", "UK Manchester", "uk-manchester.privateinternetaccess.com", false},
+	{"uk_southampton", "UK Southampton", "uk-southampton.privateinternetaccess.com", false},
+	{"us_california", "US California", "us-california.privateinternetaccess.com", false},
+	{"us_chicago", "US Chicago", "us-chicago.privateinternetaccess.com", false},
+	{"us_east", "US East", "us-east.privateinternetaccess.com", false},
+	{"us_florida", "US Florida", "us-florida.privateinternetaccess.com", false},
+	{"us_new_york_city", "US New York City", "us-newyorkcity.privateinternetaccess.com", false},
+	{"us_seattle", "US Seattle", "us-seattle.privateinternetaccess.com", false},
+	{"us_silicon_valley", "US Silicon Valley", "us-siliconvalley.privateinternetaccess.com", false},
+	{"us_texas", "US Texas", "us-texas.privateinternetaccess.com", false},
+	{"us_west", "US West", "us-west.privateinternetaccess.com", false},
+}
+
+// LoadRegions is the region list from url, fetched at most once every
+// regionsTTL, or the built in list when url is empty. When the fetch fails
+// the built in list is returned with the error and used until the next try.
+func LoadRegions(url string, timeout time.Duration) ([]*Region, error) {
+	if url == "" {
+		return Regions, nil
+	}
+	fetched.Lock()
+	defer fetched.Unlock()
+	if fetched.url == url && time.Since(fetched.at) < regionsTTL {
+		return fetched.regions, nil
+	}
+
+	rs, er := FetchRegions(url, timeout)
+	if er != nil {
+		rs = Regions
+	}
+	fetched.url, fetched.at, fetched.regions = url, time.Now(), rs
+	return rs, er
+}
+
+// FindRegion looks a region up by id, name or host.
+func FindRegion(regions []*Region, name string) (*Region, error) {
+	for _, r := range regions {
+		if r.ID == name || r.Name == name || r.Host == name {
+			return r, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown PIA region %q", name)
+}
+
+// FetchRegions downloads the region list from url. It reads PIA's vpninfo
+// format, an object of regions keyed by id, and the newer format with a
+// regions array.
+func FetchRegions(url string, timeout time.Duration) ([]*Region, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, er := client.Get(url)
+	if er != nil {
+		return nil, er
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Region list %s: %s", url, resp.Status)
+	}
+
+	// The newer format is followed by a signature, only the first json
+	// value is the list.
+	var raw map[string]*json.RawMessage
+	if er := json.NewDecoder(resp.Body).Decode(&raw); er != nil {
+		return nil, er
+	}
+	return parseRegions(raw)
+}
+
+type vpnInfoRegion struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DNS         string `json:"dns"`
+	PortForward bool   `json:"port_forward"`
+}
+
+func parseRegions(raw map[string]*json.RawMessage) ([]*Region, error) {
+	var list []*vpnInfoRegion
+	if r, ok := raw["regions"]; ok && r != nil {
+		if er := json.Unmarshal(*r, &list); er != nil {
+			return nil, er
+		}
+	} else {
+		for id, r := range raw {
+			v := new(vpnInfoRegion)
+			if r == nil || json.Unmarshal(*r, v) != nil {
+				continue
+			}
+			v.ID = id
+			list = append(list, v)
+		}
+	}
+
+	regions := make([]*Region, 0, len(list))
+	for _, v := range list {
+		if v.DNS == "" {
+			continue
+		}
+		regions = append(regions, &Region{ID: v.ID, Name: v.Name, Host: v.DNS, PortForward: v.PortForward})
+	}
+	if len(regions) < 1 {
+		return nil, fmt.Errorf("Region list has no regions")
+	}
+	sort.Sort(byID(regions))
+	return regions, nil
+}
+
+type byID []*Region
+
+func (r byID) Len() int           { return len(r) }
+func (r byID) Less(i, j int) bool { return r[i].ID < r[j].ID }
+func (r byID) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
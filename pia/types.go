@@ -1,5 +1,24 @@
 package pia
 
+import "time"
+
 type response struct {
 	Port int `json:"port"`
 }
+
+type tokenResponse struct {
+	Token string `json:"token"`
+}
+
+type gatewayResponse struct {
+	Status    string `json:"status"`
+	Message   string `json:"message"`
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
+
+type payload struct {
+	Token     string    `json:"token"`
+	Port      int       `json:"port"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/albertrdixon/transmon/api"
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/supervise"
+	"github.com/albertrdixon/transmon/vpn"
+	"golang.org/x/net/context"
+)
+
+// processes tracks transmission and the tunnel. Every start builds them
+// fresh from the current config. They run on a context of their own so
+// shutting the workers down does not kill them, shutdown stops them in
+// order.
+type processes struct {
+	sync.Mutex
+	trans  *supervise.Process
+	grace  time.Duration
+	tunnel vpn.Backend
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	closed bool
+}
+
+var errShutdown = errors.New("Shutting down")
+
+func newProcesses() *processes {
+	p := new(processes)
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return p
+}
+
+func (p *processes) startVPN(c *config.Config) error {
+	if p.isClosed() {
+		return errShutdown
+	}
+	b, er := vpn.New(c)
+	if er != nil {
+		return er
+	}
+	if er := b.Start(p.ctx); er != nil {
+		return er
+	}
+
+	p.Lock()
+	p.tunnel = b
+	p.Unlock()
+	return nil
+}
+
+func (p *processes) startTransmission(c *config.Config) error {
+	if p.isClosed() {
+		return errShutdown
+	}
+	t, er := supervise.New("transmission", c.Transmission.Command)
+	if er != nil {
+		return er
+	}
+	t.SetUser(uint32(c.Transmission.UID), uint32(c.Transmission.GID))
+
+	p.Lock()
+	p.trans, p.grace = t, c.Transmission.StopTimeout.Duration
+	p.Unlock()
+	go t.Run(p.ctx)
+	return nil
+}
+
+func (p *processes) transmissionRunning() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.trans != nil && p.trans.Running()
+}
+
+func (p *processes) isClosed() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.closed
+}
+
+// management returns the management client of the running openvpn if it
+// was started with c's command line, or nil.
+func (p *processes) management(c *config.Config) *vpn.Management {
+	p.Lock()
+	defer p.Unlock()
+	o, ok := p.tunnel.(*vpn.OpenVPN)
+	if !ok || c.VPN != config.OpenVPNTunnel || o.Command != vpn.Command(c) {
+		return nil
+	}
+	return o.Manager()
+}
+
+// states delivers openvpn state changes, it is nil without a management
+// socket.
+func (p *processes) states() <-chan *vpn.State {
+	p.Lock()
+	defer p.Unlock()
+	if o, ok := p.tunnel.(*vpn.OpenVPN); ok {
+		if m := o.Manager(); m != nil {
+			return m.States()
+		}
+	}
+	return nil
+}
+
+// stopVPN and stopTransmission wait for the process to exit without
+// holding the lock, so status stays available meanwhile.
+func (p *processes) stopVPN() {
+	p.Lock()
+	t := p.tunnel
+	p.tunnel = nil
+	p.Unlock()
+	if t != nil {
+		t.Stop()
+	}
+}
+
+func (p *processes) stopTransmission() {
+	p.Lock()
+	t, grace := p.trans, p.grace
+	p.trans = nil
+	p.Unlock()
+	if t != nil {
+		logger.Debugf("Stopping transmission, killing it after %v", grace)
+		t.Stop(grace)
+	}
+}
+
+// stop takes Transmission down before the tunnel it is bound to.
+func (p *processes) stop() {
+	p.stopTransmission()
+	p.stopVPN()
+}
+
+// shutdown stops everything for good, later starts fail.
+func (p *processes) shutdown() {
+	p.Lock()
+	p.closed = true
+	p.Unlock()
+	p.stop()
+	p.cancel()
+}
+
+func (p *processes) info() []*api.Process {
+	p.Lock()
+	defer p.Unlock()
+	info := []*api.Process{{Name: "transmission", PID: -1}}
+	if t := p.trans; t != nil {
+		info[0] = processInfo("transmission", t.Pid(), t.Running(), t.Started)
+	}
+	if p.tunnel != nil {
+		s := p.tunnel.Status()
+		info = append(info, processInfo(p.tunnel.Name(), s.PID, s.Running, s.Started))
+	}
+	return info
+}
+
+func (p *processes) tunnelStatus() *api.Tunnel {
+	p.Lock()
+	b := p.tunnel
+	p.Unlock()
+	if b == nil {
+		return nil
+	}
+
+	t := &api.Tunnel{Backend: b.Name()}
+	if o, ok := b.(*vpn.OpenVPN); ok && o.Manager() != nil {
+		m := o.Manager()
+		if s := m.State(); s != nil {
+			t.State, t.LocalIP, t.RemoteIP, t.Since = s.Name, s.LocalIP, s.RemoteIP, s.Time
+		}
+		t.BytesIn, t.BytesOut = m.Bytes()
+		return t
+	}
+	s := b.Status()
+	t.State, t.Since = s.State, s.Started
+	t.LocalIP, _ = b.IP()
+	return t
+}
+
+func processInfo(name string, pid int, running bool, started time.Time) *api.Process {
+	info := &api.Process{Name: name, PID: pid, Running: running}
+	if running {
+		info.Started = started
+		info.Uptime = time.Since(started).String()
+	}
+	return info
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/albertrdixon/transmon/api"
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/notify"
+)
+
+// state is what transmon currently knows about the tunnel and Transmission,
+// it backs the http api.
+var state = &daemon{
+	refresh: make(chan struct{}, 1),
+	restart: make(chan struct{}, 1),
+	clean:   make(chan struct{}, 1),
+}
+
+type daemon struct {
+	sync.RWMutex
+	ip, provider string
+	server       string
+	port         int
+	portOpen     *bool
+	portChecked  time.Time
+	portUpdated  time.Time
+	cleaned      time.Time
+	procs        *processes
+
+	refresh, restart, clean chan struct{}
+}
+
+// setBinding records the binding and notifies after unlocking, so a slow
+// sink does not hold up Status.
+func (d *daemon) setBinding(ip string, port int) {
+	d.Lock()
+	old := d.port
+	d.ip, d.port, d.portUpdated = ip, port, time.Now()
+	d.Unlock()
+
+	if port != old && old != 0 {
+		notify.Send(config.EventPortChanged, "transmon: peer port changed",
+			fmt.Sprintf("Transmission peer port is now %d on %s", port, ip),
+			map[string]string{"ip": ip, "port": strconv.Itoa(port), "old_port": strconv.Itoa(old)})
+	}
+}
+
+func (d *daemon) setPortOpen(open bool) {
+	d.Lock()
+	defer d.Unlock()
+	d.portOpen, d.portChecked = &open, time.Now()
+}
+
+func (d *daemon) setProvider(name string) {
+	d.Lock()
+	defer d.Unlock()
+	d.provider = name
+}
+
+func (d *daemon) setServer(name string) {
+	d.Lock()
+	defer d.Unlock()
+	d.server = name
+}
+
+func (d *daemon) setProcesses(p *processes) {
+	d.Lock()
+	defer d.Unlock()
+	d.procs = p
+}
+
+func (d *daemon) setCleaned(t time.Time) {
+	d.Lock()
+	defer d.Unlock()
+	d.cleaned = t
+}
+
+func (d *daemon) IP() string {
+	d.RLock()
+	defer d.RUnlock()
+	return d.ip
+}
+
+func (d *daemon) Port() int {
+	d.RLock()
+	defer d.RUnlock()
+	return d.port
+}
+
+func (d *daemon) Status() *api.Status {
+	d.RLock()
+	defer d.RUnlock()
+	s := &api.Status{
+		BindIP:      d.ip,
+		Port:        d.port,
+		Provider:    d.provider,
+		Server:      d.server,
+		PortOpen:    d.portOpen,
+		PortChecked: d.portChecked,
+		PortUpdated: d.portUpdated,
+		Cleaned:     d.cleaned,
+	}
+	if d.procs != nil {
+		s.Processes = d.procs.info()
+		s.Tunnel = d.procs.tunnelStatus()
+	}
+	return s
+}
+
+func (d *daemon) RefreshPort() error {
+	return trigger(d.refresh, "port refresh")
+}
+
+func (d *daemon) RestartVPN() error {
+	return trigger(d.restart, "vpn restart")
+}
+
+func (d *daemon) Clean() error {
+	return trigger(d.clean, "cleaner run")
+}
+
+func trigger(ch chan struct{}, name string) error {
+	select {
+	case ch <- struct{}{}:
+		return nil
+	default:
+		return api.Busy(name)
+	}
+}
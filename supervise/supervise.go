@@ -0,0 +1,131 @@
+// Package supervise keeps child processes running. A gearbox process can
+// only be stopped once, so every start builds a fresh Process.
+package supervise
+
+import (
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/albertrdixon/gearbox/process"
+	"github.com/albertrdixon/transmon/logging"
+	"github.com/albertrdixon/transmon/metrics"
+	"golang.org/x/net/context"
+)
+
+var logger = logging.New("supervise")
+
+// Process restarts its command whenever it exits until it is stopped. This
+// is gearbox's ExecuteAndRestart with the restarts counted and a stop that
+// gives the command time to exit.
+type Process struct {
+	*process.Process
+	Name, Cmd string
+	Started   time.Time
+	finished  chan struct{}
+
+	mu       sync.Mutex
+	pid      int
+	running  bool
+	stopping bool
+}
+
+func New(name, cmd string) (*Process, error) {
+	p, er := process.New(name, cmd, logging.New(name).Writer())
+	if er != nil {
+		return nil, er
+	}
+	return &Process{Process: p, Name: name, Cmd: cmd, Started: time.Now(), finished: make(chan struct{}), pid: -1}, nil
+}
+
+// Run starts the command and restarts it until Stop is called or ctx is
+// done, which kills it. It returns once the command has exited.
+func (p *Process) Run(ctx context.Context) {
+	defer close(p.finished)
+	defer p.set(-1, false)
+	for {
+		if er := p.Execute(ctx); er != nil {
+			logger.Errorf("Failed to start %s: %v", p.Name, er)
+			return
+		}
+		if !p.started(p.Process.Pid()) {
+			p.Signal(syscall.SIGTERM)
+		}
+
+		<-p.Exited()
+		p.set(-1, false)
+		if p.stopped() || ctx.Err() != nil {
+			return
+		}
+		logger.Event("process_restarted", logging.Fields{"process": p.Name}).Warnf("%s exited, restarting", p.Name)
+		metrics.ProcessRestarts.With(p.Name).Inc()
+	}
+}
+
+// Stop sends SIGTERM and waits up to grace for the command to exit before
+// killing it. Run must have been called.
+func (p *Process) Stop(grace time.Duration) {
+	p.mu.Lock()
+	if p.stopping {
+		p.mu.Unlock()
+		<-p.finished
+		return
+	}
+	p.stopping = true
+	pid := p.pid
+	p.mu.Unlock()
+
+	if pid > 0 {
+		logger.Debugf("Sending SIGTERM to %s (pid %d)", p.Name, pid)
+		p.Signal(syscall.SIGTERM)
+	}
+	select {
+	case <-p.finished:
+		return
+	case <-time.After(grace):
+	}
+	if p.Pid() > 0 {
+		logger.Event("process_killed", logging.Fields{"process": p.Name, "duration": grace}).Warnf("%s did not exit within %v, killing it", p.Name, grace)
+		p.Kill()
+	}
+	<-p.finished
+}
+
+// started records a running pid and reports false when Stop came first.
+func (p *Process) started(pid int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pid, p.running = pid, true
+	return !p.stopping
+}
+
+func (p *Process) stopped() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.stopping
+}
+
+func (p *Process) set(pid int, running bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pid, p.running = pid, running
+}
+
+// Pid is -1 while the process is not running, or when p is nil.
+func (p *Process) Pid() int {
+	if p == nil {
+		return -1
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pid
+}
+
+func (p *Process) Running() bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running
+}
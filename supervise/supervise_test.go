@@ -0,0 +1,72 @@
+package supervise
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestProcess(t *testing.T) {
+	is := assert.New(t)
+	p, er := New("sleeper", "sleep 30")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	is.Equal("sleep 30", p.Cmd)
+	is.Equal(-1, p.Pid(), "not started yet")
+	is.False(p.Running())
+
+	var none *Process
+	is.Equal(-1, none.Pid())
+	is.False(none.Running())
+
+	done := make(chan struct{})
+	go func() {
+		p.Run(context.Background())
+		close(done)
+	}()
+	for i := 0; i < 50 && !p.Running(); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	is.True(p.Running())
+
+	p.Stop(5 * time.Second)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after Stop")
+	}
+	is.False(p.Running())
+	is.Equal(-1, p.Pid())
+	p.Stop(time.Second)
+}
+
+func TestStopKills(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "supervise")
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+	script := filepath.Join(dir, "stubborn.sh")
+	ioutil.WriteFile(script, []byte("trap '' TERM\nsleep 3\n"), 0755)
+
+	p, er := New("stubborn", "sh "+script)
+	if !is.NoError(er) {
+		t.FailNow()
+	}
+	go p.Run(context.Background())
+	for i := 0; i < 50 && !p.Running(); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	start := time.Now()
+	p.Stop(200 * time.Millisecond)
+	is.True(time.Since(start) >= 200*time.Millisecond, "TERM is ignored so Stop waits out the grace period")
+	is.False(p.Running())
+}
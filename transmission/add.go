@@ -0,0 +1,55 @@
+package transmission
+
+import (
+	"fmt"
+
+	"github.com/tubbebubbe/transmission"
+)
+
+// Added is a torrent Transmission accepted. Duplicate is set when it
+// already had it.
+type Added struct {
+	ID        int
+	Name      string
+	Hash      string
+	Duplicate bool
+}
+
+// AddFile adds the .torrent file at path, downloading to dir when it is
+// not empty.
+func (c *Client) AddFile(path, dir string) (*Added, error) {
+	cmd, er := transmission.NewAddCmdByFile(path)
+	if er != nil {
+		return nil, er
+	}
+	return c.add(cmd, dir)
+}
+
+// AddMagnet adds a magnet link, downloading to dir when it is not empty.
+func (c *Client) AddMagnet(link, dir string) (*Added, error) {
+	cmd, er := transmission.NewAddCmdByMagnet(link)
+	if er != nil {
+		return nil, er
+	}
+	return c.add(cmd, dir)
+}
+
+func (c *Client) add(cmd *transmission.Command, dir string) (*Added, error) {
+	if dir != "" {
+		cmd.SetDownloadDir(dir)
+	}
+	out, er := c.ExecuteCommand(cmd)
+	if er != nil {
+		return nil, er
+	}
+	switch out.Result {
+	case "success":
+	case "duplicate torrent":
+		return &Added{Duplicate: true}, nil
+	default:
+		return nil, fmt.Errorf("torrent-add: %s", out.Result)
+	}
+	// Newer versions answer duplicates with torrent-duplicate instead.
+	t := out.Arguments.TorrentAdded
+	return &Added{ID: t.ID, Name: t.Name, Hash: t.HashString, Duplicate: t.HashString == ""}, nil
+}
@@ -0,0 +1,191 @@
+package transmission
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/logging"
+)
+
+// Decision is what the cleaner wants to do with one torrent and why.
+type Decision struct {
+	ID      int    `json:"id"`
+	Hash    string `json:"hash"`
+	Name    string `json:"name"`
+	Rule    string `json:"rule"`
+	Action  string `json:"action"`
+	Reason  string `json:"reason"`
+	Applied bool   `json:"applied"`
+	rule    *config.CleanerRule
+}
+
+// String is the decision as clean prints it.
+func (d *Decision) String() string {
+	return fmt.Sprintf("[Torrent %d: %q] %s (rule %q: %s)", d.ID, d.Name, d.Action, d.Rule, d.Reason)
+}
+
+// message is the decision for logs, which carry the torrent in fields.
+func (d *Decision) message() string {
+	return fmt.Sprintf("%s %q (rule %q: %s)", d.Action, d.Name, d.Rule, d.Reason)
+}
+
+func (d *Decision) fields() logging.Fields {
+	f := torrentLog(d.ID, d.Hash, d.Name)
+	f["rule"], f["action"], f["reason"] = d.Rule, d.Action, d.Reason
+	return f
+}
+
+func torrentLog(id int, hash, name string) logging.Fields {
+	return logging.Fields{"torrent_id": id, "torrent_hash": hash, "torrent_name": name}
+}
+
+func (d *Decision) removes() bool {
+	return d.Action == config.ActionRemove || d.Action == config.ActionRemoveData
+}
+
+// decide returns the action of the first rule matching s, or nil.
+func decide(rules []*config.CleanerRule, s *torrentStatus, limits *seedLimits, now time.Time) *Decision {
+	for _, r := range rules {
+		why, ok := match(r.Match, s, now)
+		if !ok || applied(r, s.Torrent) {
+			continue
+		}
+
+		d := &Decision{ID: s.ID, Hash: s.HashString, Name: s.Name, Rule: r.Name, Action: r.Action, Reason: joinReasons(why), rule: r}
+		if d.removes() && seedingToRatio(s.Torrent, limits) {
+			d.Action, d.Reason = "keep", fmt.Sprintf("still seeding to ratio target (%.2f)", s.UploadRatio)
+		}
+		return d
+	}
+	return nil
+}
+
+func match(m *config.RuleMatch, s *torrentStatus, now time.Time) ([]string, bool) {
+	why := make([]string, 0, 2)
+	if m.Finished != nil {
+		if s.IsFinished != *m.Finished {
+			return nil, false
+		}
+		if s.IsFinished {
+			why = append(why, "finished")
+		}
+	}
+	if m.Complete != nil && s.Complete() != *m.Complete {
+		return nil, false
+	}
+	if m.Ratio > 0 {
+		if s.UploadRatio < m.Ratio {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("ratio %.2f >= %.2f", s.UploadRatio, m.Ratio))
+	}
+	if m.SeedingTime != nil {
+		seeding := time.Duration(s.SecondsSeeding) * time.Second
+		if seeding < m.SeedingTime.Duration {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("seeding for %v", seeding))
+	}
+	if m.Age != nil {
+		age := now.Sub(s.Added())
+		if age < m.Age.Duration {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("added %v ago", age))
+	}
+	if m.Stalled > 0 {
+		if s.stalled < m.Stalled {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("stalled %d cycles", s.stalled))
+	}
+	if m.Errored > 0 {
+		if s.errored < m.Errored {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("errored %d cycles: %s", s.errored, s.ErrorString))
+	}
+	if m.Error != nil {
+		if s.Error == 0 || !m.Error.MatchString(s.ErrorString) {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("error %q", s.ErrorString))
+	}
+	if m.DownloadDir != nil {
+		if !m.DownloadDir.MatchString(s.DownloadDir) {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("in %s", s.DownloadDir))
+	}
+	if m.Tracker != nil {
+		found := ""
+		for _, t := range s.Trackers {
+			if m.Tracker.MatchString(t.Announce) {
+				found = t.Announce
+				break
+			}
+		}
+		if found == "" {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("tracker %s", found))
+	}
+	if m.Label != "" {
+		if !hasLabel(s.Torrent, m.Label) {
+			return nil, false
+		}
+		why = append(why, fmt.Sprintf("label %s", m.Label))
+	}
+	return why, true
+}
+
+// applied reports whether a non destructive action has already been done,
+// so the same torrent is not stopped, moved or labeled every run.
+func applied(r *config.CleanerRule, t *Torrent) bool {
+	switch r.Action {
+	case config.ActionStop:
+		return t.Status == StatusStopped
+	case config.ActionMove:
+		return filepath.Clean(t.DownloadDir) == filepath.Clean(r.Location)
+	case config.ActionLabel:
+		return hasLabel(t, r.Label)
+	}
+	return false
+}
+
+// seedingToRatio reports whether t is seeding and has not reached the seed
+// ratio it is configured to stop at.
+func seedingToRatio(t *Torrent, limits *seedLimits) bool {
+	if t.Status != StatusSeed && t.Status != StatusSeedWait {
+		return false
+	}
+	var target float64
+	switch t.SeedRatioMode {
+	case RatioSingle:
+		target = t.SeedRatioLimit
+	case RatioGlobal:
+		if limits != nil && limits.Limited {
+			target = limits.Limit
+		}
+	}
+	return target > 0 && t.UploadRatio < target
+}
+
+func hasLabel(t *Torrent, label string) bool {
+	for _, l := range t.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func joinReasons(why []string) string {
+	if len(why) < 1 {
+		return "matched"
+	}
+	return strings.Join(why, ", ")
+}
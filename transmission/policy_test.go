@@ -0,0 +1,83 @@
+package transmission
+
+import (
+	"testing"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func rules(t *testing.T) []*config.CleanerRule {
+	c, er := config.Read("../config/examples/config.yml")
+	if er != nil {
+		t.Fatal(er)
+	}
+	return c.Cleaner.Rules
+}
+
+func TestDecide(t *testing.T) {
+	is := assert.New(t)
+	var (
+		rs  = rules(t)
+		now = time.Now()
+	)
+
+	seeding := &torrentStatus{Torrent: &Torrent{
+		ID: 1, Name: "seeding", Status: StatusSeed, PercentDone: 1,
+		UploadRatio: 2.5, SecondsSeeding: 100 * 3600, SeedRatioMode: RatioSingle, SeedRatioLimit: 3,
+	}}
+	d := decide(rs, seeding, nil, now)
+	if is.NotNil(d) {
+		is.Equal("seeded", d.Rule)
+		is.Equal("keep", d.Action, "torrents short of their seed ratio must not be removed")
+	}
+
+	seeding.UploadRatio = 3.1
+	d = decide(rs, seeding, nil, now)
+	if is.NotNil(d) {
+		is.Equal(config.ActionRemove, d.Action)
+		is.Contains(d.Reason, "ratio 3.10 >= 2.00")
+		is.Equal(`remove "seeding" (rule "seeded": `+d.Reason+")", d.message(), "logs carry the torrent in fields")
+		is.Equal(`[Torrent 1: "seeding"] remove (rule "seeded": `+d.Reason+")", d.String(), "clean prints the bracketed form")
+	}
+
+	errored := &torrentStatus{Torrent: &Torrent{ID: 2, Error: 2, ErrorString: "Unregistered torrent", PercentDone: 0.4}}
+	d = decide(rs, errored, nil, now)
+	if is.NotNil(d) {
+		is.Equal("unregistered", d.Rule)
+		is.Equal(config.ActionRemoveData, d.Action)
+	}
+
+	stalled := &torrentStatus{Torrent: &Torrent{ID: 3, PercentDone: 0.4}, stalled: 2}
+	is.Nil(decide(rs, stalled, nil, now))
+	stalled.stalled = 3
+	d = decide(rs, stalled, nil, now)
+	if is.NotNil(d) {
+		is.Equal("stalled", d.Rule)
+		is.Equal("stalled 3 cycles", d.Reason)
+	}
+
+	movie := &torrentStatus{Torrent: &Torrent{ID: 4, PercentDone: 1, DownloadDir: "/downloads/movies/x"}}
+	d = decide(rs, movie, nil, now)
+	if is.NotNil(d) {
+		is.Equal(config.ActionMove, d.Action)
+	}
+	movie.DownloadDir = "/media/movies"
+	is.Nil(decide(rs, movie, nil, now), "already moved torrents are left alone")
+}
+
+func TestSeedingToRatio(t *testing.T) {
+	is := assert.New(t)
+	tr := &Torrent{Status: StatusSeed, UploadRatio: 1, SeedRatioMode: RatioGlobal}
+
+	is.False(seedingToRatio(tr, nil))
+	is.False(seedingToRatio(tr, &seedLimits{Limited: false, Limit: 2}))
+	is.True(seedingToRatio(tr, &seedLimits{Limited: true, Limit: 2}))
+
+	tr.SeedRatioMode = RatioUnlimited
+	is.False(seedingToRatio(tr, &seedLimits{Limited: true, Limit: 2}))
+
+	tr.SeedRatioMode, tr.SeedRatioLimit, tr.Status = RatioSingle, 2, StatusStopped
+	is.False(seedingToRatio(tr, nil))
+}
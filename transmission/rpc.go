@@ -0,0 +1,109 @@
+package transmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const sessionHeader = "X-Transmission-Session-Id"
+
+// Args are the arguments of an rpc request.
+type Args map[string]interface{}
+
+// RawClient speaks transmission's rpc protocol. It keeps the session id
+// transmission hands out and retries a request once when it changes.
+type RawClient struct {
+	tag     int64
+	url     string
+	user    string
+	pass    string
+	client  *http.Client
+	mu      sync.Mutex
+	session string
+}
+
+func NewRawClient(url, user, pass string) *RawClient {
+	return &RawClient{
+		url:    strings.TrimSuffix(url, "/") + "/transmission/rpc",
+		user:   user,
+		pass:   pass,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// call sends method with args and decodes the arguments of the response
+// into out, when out is not nil. The response must carry the request's tag.
+func (r *RawClient) call(method string, args, out interface{}) error {
+	req := &request{Method: method, Tag: atomic.AddInt64(&r.tag, 1), Args: args}
+	body, er := json.Marshal(req)
+	if er != nil {
+		return er
+	}
+	data, er := r.post(body)
+	if er != nil {
+		return fmt.Errorf("%s: %v", method, er)
+	}
+
+	resp := new(response)
+	if er := json.Unmarshal(data, resp); er != nil {
+		return fmt.Errorf("%s: %v", method, er)
+	}
+	if resp.Tag != req.Tag {
+		return fmt.Errorf("%s: response tag %d does not match request tag %d", method, resp.Tag, req.Tag)
+	}
+	if resp.Result != "success" {
+		return fmt.Errorf("%s: %s", method, resp.Result)
+	}
+	if out == nil || len(resp.Args) < 1 {
+		return nil
+	}
+	return json.Unmarshal(resp.Args, out)
+}
+
+// post sends body, getting a new session id and trying again when
+// transmission answers 409 Conflict.
+func (r *RawClient) post(body []byte) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, er := http.NewRequest("POST", r.url, bytes.NewReader(body))
+		if er != nil {
+			return nil, er
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if r.user != "" || r.pass != "" {
+			req.SetBasicAuth(r.user, r.pass)
+		}
+		r.mu.Lock()
+		if r.session != "" {
+			req.Header.Set(sessionHeader, r.session)
+		}
+		r.mu.Unlock()
+
+		resp, er := r.client.Do(req)
+		if er != nil {
+			return nil, er
+		}
+		data, er := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if er != nil {
+			return nil, er
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusConflict && attempt < 1:
+			r.mu.Lock()
+			r.session = resp.Header.Get(sessionHeader)
+			r.mu.Unlock()
+			continue
+		case resp.StatusCode != http.StatusOK:
+			return nil, fmt.Errorf("POST %s: %s", r.url, resp.Status)
+		}
+		return data, nil
+	}
+}
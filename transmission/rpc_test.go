@@ -0,0 +1,93 @@
+package transmission
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rpcServer struct {
+	requests []*request
+	conflict int
+	reply    func(r *request) (result string, tag int64, args interface{})
+}
+
+func (s *rpcServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get(sessionHeader) != "session-2" {
+		s.conflict++
+		w.Header().Set(sessionHeader, "session-2")
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	var req request
+	json.NewDecoder(r.Body).Decode(&req)
+	s.requests = append(s.requests, &req)
+	result, tag, args := s.reply(&req)
+	json.NewEncoder(w).Encode(map[string]interface{}{"result": result, "tag": tag, "arguments": args})
+}
+
+func TestRPC(t *testing.T) {
+	is := assert.New(t)
+	s := &rpcServer{reply: func(r *request) (string, int64, interface{}) {
+		switch r.Method {
+		case "torrent-get":
+			return "success", r.Tag, map[string]interface{}{"torrents": []map[string]interface{}{
+				{"id": 1, "hashString": "abc", "name": "ubuntu.iso", "percentDone": 1},
+			}}
+		case "port-test":
+			return "success", r.Tag, map[string]interface{}{"port-is-open": true}
+		case "free-space":
+			return "success", r.Tag, map[string]interface{}{"path": "/data", "size-bytes": 1024}
+		case "torrent-rename-path":
+			return "success", r.Tag, map[string]interface{}{"id": 1, "path": "a", "name": "b"}
+		case "session-stats":
+			return "success", r.Tag + 1, nil
+		case "blocklist-update":
+			return "blocklist update failed", r.Tag, nil
+		}
+		return "success", r.Tag, nil
+	}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	c := NewRawClient(srv.URL, "user", "pass")
+
+	torrents, er := c.TorrentGet([]string{"id", "hashString", "name", "percentDone"}, 1)
+	if is.NoError(er) && is.Len(torrents, 1) {
+		is.Equal("abc", torrents[0].HashString)
+		is.True(torrents[0].Complete())
+	}
+	is.Equal(1, s.conflict, "a 409 is retried with the new session id")
+	args := s.requests[0].Args.(map[string]interface{})
+	is.Equal([]interface{}{"id", "hashString", "name", "percentDone"}, args["fields"])
+	is.Equal([]interface{}{float64(1)}, args["ids"])
+
+	open, er := c.PortTest()
+	is.NoError(er)
+	is.True(open)
+	free, er := c.FreeSpace("/data")
+	is.NoError(er)
+	is.Equal(int64(1024), free)
+	renamed, er := c.TorrentRenamePath(1, "a", "b")
+	if is.NoError(er) {
+		is.Equal("b", renamed.Name)
+	}
+	is.NoError(c.TorrentSet([]int{1}, Args{"labels": []string{"done"}}))
+	is.NoError(c.QueueMoveTop(1, 2))
+	is.Equal(1, s.conflict, "the session id is kept")
+
+	_, er = c.SessionStats()
+	is.Error(er, "mismatched tags are an error")
+	_, er = c.BlocklistUpdate()
+	is.EqualError(er, "blocklist-update: blocklist update failed")
+
+	var tags = map[int64]bool{}
+	for _, r := range s.requests {
+		is.False(tags[r.Tag], "tags are unique")
+		tags[r.Tag] = true
+	}
+	is.Equal("torrent-set", s.requests[4].Method)
+	is.Equal("queue-move-top", s.requests[5].Method)
+}
@@ -0,0 +1,84 @@
+package transmission
+
+// Session is the part of session-get transmon cares about.
+type Session struct {
+	Version               string  `json:"version"`
+	RPCVersion            int     `json:"rpc-version"`
+	ConfigDir             string  `json:"config-dir"`
+	DownloadDir           string  `json:"download-dir"`
+	PeerPort              int     `json:"peer-port"`
+	PeerPortRandomOnStart bool    `json:"peer-port-random-on-start"`
+	PortForwardingEnabled bool    `json:"port-forwarding-enabled"`
+	SeedRatioLimit        float64 `json:"seedRatioLimit"`
+	SeedRatioLimited      bool    `json:"seedRatioLimited"`
+	SpeedLimitDown        int     `json:"speed-limit-down"`
+	SpeedLimitDownEnabled bool    `json:"speed-limit-down-enabled"`
+	SpeedLimitUp          int     `json:"speed-limit-up"`
+	SpeedLimitUpEnabled   bool    `json:"speed-limit-up-enabled"`
+	BlocklistEnabled      bool    `json:"blocklist-enabled"`
+	BlocklistURL          string  `json:"blocklist-url"`
+	BlocklistSize         int     `json:"blocklist-size"`
+}
+
+// SessionStats is session-stats.
+type SessionStats struct {
+	ActiveTorrentCount int   `json:"activeTorrentCount"`
+	PausedTorrentCount int   `json:"pausedTorrentCount"`
+	TorrentCount       int   `json:"torrentCount"`
+	DownloadSpeed      int64 `json:"downloadSpeed"`
+	UploadSpeed        int64 `json:"uploadSpeed"`
+	Cumulative         Stats `json:"cumulative-stats"`
+	Current            Stats `json:"current-stats"`
+}
+
+type Stats struct {
+	UploadedBytes   int64 `json:"uploadedBytes"`
+	DownloadedBytes int64 `json:"downloadedBytes"`
+	FilesAdded      int64 `json:"filesAdded"`
+	SessionCount    int64 `json:"sessionCount"`
+	SecondsActive   int64 `json:"secondsActive"`
+}
+
+// SessionGet returns the session, limited to fields when any are given.
+func (r *RawClient) SessionGet(fields ...string) (*Session, error) {
+	var args Args
+	if len(fields) > 0 {
+		args = Args{"fields": fields}
+	}
+	s := new(Session)
+	return s, r.call("session-get", args, s)
+}
+
+// SessionSet changes session settings, keyed by their rpc names.
+func (r *RawClient) SessionSet(args Args) error {
+	return r.call("session-set", args, nil)
+}
+
+func (r *RawClient) SessionStats() (*SessionStats, error) {
+	s := new(SessionStats)
+	return s, r.call("session-stats", nil, s)
+}
+
+// BlocklistUpdate fetches the blocklist and returns its size.
+func (r *RawClient) BlocklistUpdate() (int, error) {
+	var out struct {
+		Size int `json:"blocklist-size"`
+	}
+	return out.Size, r.call("blocklist-update", nil, &out)
+}
+
+// FreeSpace is the free space in bytes at path on transmission's host.
+func (r *RawClient) FreeSpace(path string) (int64, error) {
+	var out struct {
+		Size int64 `json:"size-bytes"`
+	}
+	return out.Size, r.call("free-space", Args{"path": path}, &out)
+}
+
+// PortTest asks transmission whether its peer port is reachable.
+func (r *RawClient) PortTest() (bool, error) {
+	var out struct {
+		Open bool `json:"port-is-open"`
+	}
+	return out.Open, r.call("port-test", nil, &out)
+}
@@ -0,0 +1,321 @@
+package transmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/logging"
+)
+
+// restartKeys are settings.json keys session-set cannot change. Transmission
+// only picks up new values for them when it starts.
+var restartKeys = map[string]bool{
+	config.BindKey:                true,
+	"bind-address-ipv6":           true,
+	"rpc-enabled":                 true,
+	"rpc-bind-address":            true,
+	"rpc-port":                    true,
+	"rpc-url":                     true,
+	"rpc-authentication-required": true,
+	"rpc-username":                true,
+	"rpc-password":                true,
+	"rpc-whitelist":               true,
+	"rpc-whitelist-enabled":       true,
+	"rpc-host-whitelist":          true,
+	"rpc-host-whitelist-enabled":  true,
+	"peer-socket-tos":             true,
+	"message-level":               true,
+	"umask":                       true,
+	"watch-dir":                   true,
+	"watch-dir-enabled":           true,
+	"peer-congestion-algorithm":   true,
+	"peer-port-random-high":       true,
+	"peer-port-random-low":        true,
+	"preallocation":               true,
+	"prefetch-enabled":            true,
+	"upload-slots-per-torrent":    true,
+}
+
+// NeedsRestart reports whether key can only be changed by restarting
+// Transmission with a new settings.json.
+func NeedsRestart(key string) bool {
+	return restartKeys[key]
+}
+
+// Changed returns the settings whose values differ from the ones in the
+// settings.json at path.
+func Changed(path string, settings Args) (Args, error) {
+	current, er := readSettings(path)
+	if er != nil {
+		return nil, er
+	}
+	changed := make(Args)
+	for k, v := range settings {
+		if old, ok := current[k]; !ok || !same(old, v) {
+			changed[k] = v
+		}
+	}
+	return changed, nil
+}
+
+func readSettings(path string) (Args, error) {
+	data, er := ioutil.ReadFile(path)
+	if er != nil {
+		return nil, er
+	}
+	s := make(Args)
+	if er := json.Unmarshal(data, &s); er != nil {
+		return nil, fmt.Errorf("%s: %v", path, er)
+	}
+	return s, nil
+}
+
+// same compares two values by their json, so 1 and 1.0 are the same.
+func same(a, b interface{}) bool {
+	x, er := json.Marshal(a)
+	if er != nil {
+		return false
+	}
+	y, er := json.Marshal(b)
+	return er == nil && bytes.Equal(x, y)
+}
+
+// rpcNames are the session-set names of settings.json keys that have
+// different ones.
+var rpcNames = map[string]string{
+	"ratio-limit":         "seedRatioLimit",
+	"ratio-limit-enabled": "seedRatioLimited",
+}
+
+// encryptionModes are settings.json encryption values as session-set wants
+// them.
+var encryptionModes = []string{"tolerated", "preferred", "required"}
+
+// sessionArg is the session-set name and value of a settings.json key.
+func sessionArg(k string, v interface{}) (string, interface{}) {
+	if k == "encryption" {
+		var n int
+		if b, er := json.Marshal(v); er == nil && json.Unmarshal(b, &n) == nil && n >= 0 && n < len(encryptionModes) {
+			return k, encryptionModes[n]
+		}
+	}
+	if name, ok := rpcNames[k]; ok {
+		return name, v
+	}
+	return k, v
+}
+
+// ApplySettings writes the settings that differ from the settings file into
+// it and sets the ones that can change live on the running Transmission. It
+// reports whether any that changed only take effect after a restart. r is
+// nil when Transmission is not running.
+func ApplySettings(f *SettingsFile, r *RawClient, s Args) (bool, error) {
+	changed, er := Changed(f.Path, s)
+	if er != nil || len(changed) < 1 {
+		return false, er
+	}
+	if er := f.Set(changed); er != nil {
+		return false, er
+	}
+
+	restart, live := false, make(Args)
+	for k, v := range changed {
+		if NeedsRestart(k) {
+			restart = true
+			continue
+		}
+		name, value := sessionArg(k, v)
+		live[name] = value
+	}
+	logger.Event("settings_applied", logging.Fields{"changed": len(changed), "live": len(live), "restart": restart}).
+		Infof("Applying %d changed transmission settings, %d over rpc", len(changed), len(live))
+	if r == nil || len(live) < 1 {
+		return restart, nil
+	}
+	return restart, r.SessionSet(live)
+}
+
+// Drift is a setting whose value is not the one wanted. Have is nil when
+// the setting is missing.
+type Drift struct {
+	Key  string      `json:"key"`
+	Want interface{} `json:"want"`
+	Have interface{} `json:"have"`
+}
+
+type byKey []*Drift
+
+func (b byKey) Len() int           { return len(b) }
+func (b byKey) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byKey) Less(i, j int) bool { return b[i].Key < b[j].Key }
+
+// Drift compares s with the settings file.
+func (f *SettingsFile) Drift(s Args) ([]*Drift, error) {
+	current, er := readSettings(f.Path)
+	if er != nil {
+		return nil, er
+	}
+	return drift(s, func(k string, v interface{}) (interface{}, interface{}, bool) {
+		return v, current[k], true
+	}), nil
+}
+
+// Drift compares s with the session of the running Transmission, in the
+// form session-get uses. Settings session-get does not return are left out.
+func (r *RawClient) Drift(s Args) ([]*Drift, error) {
+	session := make(Args)
+	if er := r.call("session-get", nil, &session); er != nil {
+		return nil, er
+	}
+	return drift(s, func(k string, v interface{}) (interface{}, interface{}, bool) {
+		name, want := sessionArg(k, v)
+		have, ok := session[name]
+		return want, have, ok
+	}), nil
+}
+
+// drift lists the settings of s whose wanted value differs from the one get
+// has for them, skipping those get does not know.
+func drift(s Args, get func(k string, v interface{}) (want, have interface{}, ok bool)) []*Drift {
+	out := make([]*Drift, 0)
+	for k, v := range s {
+		want, have, ok := get(k, v)
+		if ok && !same(want, have) {
+			out = append(out, &Drift{Key: k, Want: want, Have: have})
+		}
+	}
+	sort.Sort(byKey(out))
+	return out
+}
+
+// ErrModified is returned when settings.json kept changing while it was
+// being edited, most likely because Transmission was writing it.
+var ErrModified = errors.New("settings.json was modified while it was being edited")
+
+// beforeReplace lets tests change the file in the middle of an edit.
+var beforeReplace func(path string)
+
+// SettingsFile is a Transmission settings.json. Edits replace it atomically
+// and keep the previous Backups versions as path.1 (newest) to path.N.
+type SettingsFile struct {
+	Path    string
+	Backups int
+}
+
+// Set validates s and writes it into the settings file, keeping every
+// other key as it was. When the file changes between reading and replacing
+// it the edit starts over, giving up with ErrModified after a few tries.
+func (f *SettingsFile) Set(s Args) error {
+	if er := config.ValidateSettings(s); er != nil {
+		return er
+	}
+	for i := 0; i < 3; i++ {
+		er := f.set(s)
+		if er != ErrModified {
+			return er
+		}
+		logger.Warnf("%s changed while it was being edited, trying again", f.Path)
+	}
+	return ErrModified
+}
+
+func (f *SettingsFile) set(s Args) error {
+	old, er := ioutil.ReadFile(f.Path)
+	if er != nil {
+		return er
+	}
+	current := make(map[string]*json.RawMessage)
+	if er := json.Unmarshal(old, &current); er != nil {
+		return fmt.Errorf("%s: %v", f.Path, er)
+	}
+	for k, v := range s {
+		b, er := json.Marshal(v)
+		if er != nil {
+			return er
+		}
+		raw := json.RawMessage(b)
+		current[k] = &raw
+	}
+	data, er := json.MarshalIndent(current, "", "    ")
+	if er != nil {
+		return er
+	}
+
+	info, er := os.Stat(f.Path)
+	if er != nil {
+		return er
+	}
+	tmp, er := ioutil.TempFile(filepath.Dir(f.Path), ".settings")
+	if er != nil {
+		return er
+	}
+	defer os.Remove(tmp.Name())
+	_, er = tmp.Write(append(data, '\n'))
+	if er == nil {
+		er = tmp.Sync()
+	}
+	if e := tmp.Close(); er == nil {
+		er = e
+	}
+	if er == nil {
+		er = keepOwner(tmp.Name(), info)
+	}
+	if er != nil {
+		return er
+	}
+
+	if beforeReplace != nil {
+		beforeReplace(f.Path)
+	}
+	now, er := ioutil.ReadFile(f.Path)
+	if er != nil {
+		return er
+	}
+	if !bytes.Equal(now, old) {
+		return ErrModified
+	}
+	if er := f.backup(old, info.Mode().Perm()); er != nil {
+		logger.Warnf("Failed to back up %s: %v", f.Path, er)
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// backup shifts path.1 to path.2 and so on, dropping the oldest, and saves
+// data as path.1.
+func (f *SettingsFile) backup(data []byte, mode os.FileMode) error {
+	if f.Backups < 1 {
+		return nil
+	}
+	for i := f.Backups; i > 1; i-- {
+		er := os.Rename(f.backupName(i-1), f.backupName(i))
+		if er != nil && !os.IsNotExist(er) {
+			return er
+		}
+	}
+	return ioutil.WriteFile(f.backupName(1), data, mode)
+}
+
+func (f *SettingsFile) backupName(n int) string {
+	return fmt.Sprintf("%s.%d", f.Path, n)
+}
+
+// keepOwner gives the file at path the mode and owner of info, so
+// Transmission can still write its settings after transmon replaced them.
+func keepOwner(path string, info os.FileInfo) error {
+	if er := os.Chmod(path, info.Mode().Perm()); er != nil {
+		return er
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || (int(st.Uid) == os.Getuid() && int(st.Gid) == os.Getgid()) {
+		return nil
+	}
+	return os.Chown(path, int(st.Uid), int(st.Gid))
+}
@@ -0,0 +1,148 @@
+package transmission
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChanged(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "settings.json")
+	ioutil.WriteFile(file, []byte(`{
+    "bind-address-ipv4": "10.0.0.2",
+    "peer-port": 51413,
+    "speed-limit-up-enabled": false
+}`), 0600)
+
+	changed, er := Changed(file, Args{config.BindKey: "10.0.0.2", config.PortKey: 51413, "speed-limit-up-enabled": false})
+	is.NoError(er)
+	is.Empty(changed)
+
+	changed, er = Changed(file, Args{config.BindKey: "10.0.0.3", config.PortKey: 51413, "alt-speed-enabled": true})
+	is.NoError(er)
+	is.Equal(Args{config.BindKey: "10.0.0.3", "alt-speed-enabled": true}, changed)
+
+	is.True(NeedsRestart(config.BindKey))
+	is.False(NeedsRestart(config.PortKey))
+
+	_, er = Changed(filepath.Join(dir, "missing.json"), Args{config.BindKey: "10.0.0.2"})
+	is.Error(er)
+}
+
+func TestSettingsFile(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+
+	f := &SettingsFile{Path: filepath.Join(dir, "settings.json"), Backups: 2}
+	ioutil.WriteFile(f.Path, []byte(`{"peer-port": 1, "some-new-key": [1, 2]}`), 0640)
+
+	for port := 2; port <= 4; port++ {
+		is.NoError(f.Set(Args{config.PortKey: port, config.BindKey: "10.0.0.2"}))
+	}
+	changed, er := Changed(f.Path, Args{config.PortKey: 4, config.BindKey: "10.0.0.2", "some-new-key": []int{1, 2}})
+	is.NoError(er)
+	is.Empty(changed, "other keys are kept")
+	changed, _ = Changed(f.backupName(1), Args{config.PortKey: 3})
+	is.Empty(changed)
+	changed, _ = Changed(f.backupName(2), Args{config.PortKey: 2})
+	is.Empty(changed)
+	_, er = os.Stat(f.backupName(3))
+	is.True(os.IsNotExist(er), "only Backups versions are kept")
+	info, _ := os.Stat(f.Path)
+	is.Equal(os.FileMode(0640), info.Mode().Perm())
+	files, _ := ioutil.ReadDir(dir)
+	is.Len(files, 3, "no temp files are left behind")
+
+	is.Error(f.Set(Args{config.PortKey: "51413"}))
+	is.Error(f.Set(Args{config.PortKey: 70000}))
+	is.Error(f.Set(Args{config.BindKey: "not-an-ip"}))
+	is.NoError(f.Set(Args{"ratio-limit": 1.5, "download-dir": "/data"}))
+	is.NoError(f.Set(Args{"umask": 2}), "unknown settings are written unchecked")
+	changed, _ = Changed(f.Path, Args{"umask": 2})
+	is.Empty(changed)
+
+	edits := 0
+	beforeReplace = func(path string) {
+		if edits++; edits == 1 {
+			ioutil.WriteFile(path, []byte(`{"peer-port": 9, "dht-enabled": false}`), 0640)
+		}
+	}
+	defer func() { beforeReplace = nil }()
+	is.NoError(f.Set(Args{config.PortKey: 5}))
+	is.Equal(2, edits, "a changed file is edited again")
+	changed, _ = Changed(f.Path, Args{config.PortKey: 5, "dht-enabled": false})
+	is.Empty(changed, "the daemon's change is kept")
+
+	beforeReplace = func(path string) {
+		edits++
+		ioutil.WriteFile(path, []byte(fmt.Sprintf(`{"peer-port": %d}`, edits)), 0640)
+	}
+	is.Equal(ErrModified, f.Set(Args{config.PortKey: 6}))
+}
+
+func TestApplySettings(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &rpcServer{reply: func(r *request) (string, int64, interface{}) {
+		if r.Method == "session-get" {
+			return "success", r.Tag, map[string]interface{}{"speed-limit-up": 100, "encryption": "preferred", "seedRatioLimit": 2}
+		}
+		return "success", r.Tag, nil
+	}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	r := NewRawClient(srv.URL, "", "")
+
+	f := &SettingsFile{Path: filepath.Join(dir, "settings.json"), Backups: 1}
+	ioutil.WriteFile(f.Path, []byte(`{"speed-limit-up": 100, "encryption": 1, "rpc-port": 9091}`), 0600)
+	want := Args{"speed-limit-up": 200, "encryption": 2, "ratio-limit": 2}
+
+	drift, er := f.Drift(want)
+	if is.NoError(er) && is.Len(drift, 3) {
+		is.Equal(&Drift{Key: "encryption", Want: 2, Have: float64(1)}, drift[0])
+		is.Equal(&Drift{Key: "ratio-limit", Want: 2}, drift[1], "missing settings have nil")
+		is.Equal("speed-limit-up", drift[2].Key)
+	}
+	drift, er = r.Drift(want)
+	if is.NoError(er) && is.Len(drift, 2) {
+		is.Equal(&Drift{Key: "encryption", Want: "required", Have: "preferred"}, drift[0])
+		is.Equal("speed-limit-up", drift[1].Key)
+	}
+
+	restart, er := ApplySettings(f, r, want)
+	is.NoError(er)
+	is.False(restart)
+	if is.Len(s.requests, 2) {
+		is.Equal("session-set", s.requests[1].Method)
+		is.Equal(map[string]interface{}{"speed-limit-up": float64(200), "encryption": "required", "seedRatioLimit": float64(2)}, s.requests[1].Args)
+	}
+	drift, _ = f.Drift(want)
+	is.Empty(drift)
+
+	restart, er = ApplySettings(f, r, Args{"speed-limit-up": 200, "rpc-port": 9092})
+	is.NoError(er)
+	is.True(restart, "rpc-port needs a restart")
+	is.Len(s.requests, 2, "nothing that can change live changed")
+}
@@ -0,0 +1,64 @@
+package transmission
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/albertrdixon/transmon/atomicfile"
+)
+
+// record is what the cleaner remembers about a torrent between runs.
+type record struct {
+	Hash        string    `json:"hash"`
+	Stalled     int       `json:"stalled"`
+	Errored     int       `json:"errored"`
+	PercentDone float64   `json:"percent_done"`
+	UploadRatio float64   `json:"upload_ratio"`
+	FirstSeen   time.Time `json:"first_seen"`
+}
+
+func newRecord(s *torrentStatus) *record {
+	return &record{
+		Hash:        s.HashString,
+		Stalled:     s.stalled,
+		Errored:     s.errored,
+		PercentDone: s.PercentDone,
+		UploadRatio: s.UploadRatio,
+		FirstSeen:   s.firstSeen,
+	}
+}
+
+// loadState reads cleaner records keyed by torrent hash. A missing file is
+// an empty state.
+func loadState(file string) (map[string]*record, error) {
+	state := make(map[string]*record)
+	data, er := ioutil.ReadFile(file)
+	if os.IsNotExist(er) {
+		return state, nil
+	} else if er != nil {
+		return state, er
+	}
+
+	var list []*record
+	if er := json.Unmarshal(data, &list); er != nil {
+		return state, er
+	}
+	for _, r := range list {
+		state[r.Hash] = r
+	}
+	return state, nil
+}
+
+func saveState(file string, state map[string]*record) error {
+	list := make([]*record, 0, len(state))
+	for _, r := range state {
+		list = append(list, r)
+	}
+	data, er := json.Marshal(list)
+	if er != nil {
+		return er
+	}
+	return atomicfile.Write(file, data)
+}
@@ -0,0 +1,133 @@
+package transmission
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "state", "cleaner.json")
+	state, er := loadState(file)
+	is.NoError(er)
+	is.Empty(state)
+
+	first := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	st := &torrentStatus{
+		Torrent:   &Torrent{HashString: "abc123", PercentDone: 0.5, UploadRatio: 0.1},
+		id:        "abc123",
+		stalled:   2,
+		errored:   1,
+		firstSeen: first,
+	}
+	is.NoError(saveState(file, map[string]*record{st.id: newRecord(st)}))
+
+	state, er = loadState(file)
+	is.NoError(er)
+	if r, ok := state["abc123"]; is.True(ok) {
+		is.Equal(2, r.Stalled)
+		is.Equal(1, r.Errored)
+		is.Equal(0.5, r.PercentDone)
+		is.True(first.Equal(r.FirstSeen))
+		is.False(updated(r, st.Torrent))
+		is.True(updated(r, &Torrent{PercentDone: 0.6, UploadRatio: 0.1}))
+	}
+
+	left, _ := filepath.Glob(filepath.Join(dir, "state", ".cleaner.json*"))
+	is.Empty(left)
+}
+
+func TestCleanDryRun(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &rpcServer{reply: func(r *request) (string, int64, interface{}) {
+		if r.Method == "torrent-get" {
+			return "success", r.Tag, map[string]interface{}{"torrents": []map[string]interface{}{
+				{"id": 1, "hashString": "abc", "name": "gone", "error": 2, "errorString": "Unregistered torrent", "percentDone": 0.4},
+			}}
+		}
+		return "success", r.Tag, nil
+	}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	file := filepath.Join(dir, "cleaner.json")
+	is.NoError(saveState(file, map[string]*record{}))
+	before, _ := ioutil.ReadFile(file)
+	defer resetSeen()
+
+	c := NewClient(srv.URL, "user", "pass")
+	c.StateFile = file
+	decisions, er := c.CleanTorrents(rules(t), true)
+	is.NoError(er)
+	if is.Len(decisions, 1) {
+		is.Equal(config.ActionRemoveData, decisions[0].Action)
+		is.False(decisions[0].Applied)
+	}
+	after, _ := ioutil.ReadFile(file)
+	is.Equal(string(before), string(after), "a dry run leaves the state alone")
+	for _, r := range s.requests {
+		is.NotEqual("torrent-remove", r.Method)
+	}
+}
+
+func TestCleanDryRunCounts(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &rpcServer{reply: func(r *request) (string, int64, interface{}) {
+		if r.Method == "torrent-get" {
+			return "success", r.Tag, map[string]interface{}{"torrents": []map[string]interface{}{
+				{"id": 1, "hashString": "abc", "name": "broken", "error": 3, "errorString": "No data found", "percentDone": 0.4},
+			}}
+		}
+		return "success", r.Tag, nil
+	}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	file := filepath.Join(dir, "cleaner.json")
+	is.NoError(saveState(file, map[string]*record{}))
+	before, _ := ioutil.ReadFile(file)
+	resetSeen()
+	defer resetSeen()
+
+	c := NewClient(srv.URL, "user", "pass")
+	c.StateFile = file
+	for i := 1; i <= 2; i++ {
+		_, er := c.CleanTorrents(rules(t), true)
+		is.NoError(er)
+		if r, ok := seen["abc"]; is.True(ok) {
+			is.Equal(i, r.Errored, "dry runs count errors like real runs")
+			is.Equal(i-1, r.Stalled)
+		}
+	}
+	after, _ := ioutil.ReadFile(file)
+	is.Equal(string(before), string(after), "dry runs keep their counts in memory")
+}
+
+func resetSeen() {
+	seen, unsaved = make(map[string]*record), false
+}
@@ -0,0 +1,161 @@
+package transmission
+
+import (
+	"errors"
+	"time"
+)
+
+// Torrent status values from the rpc spec.
+const (
+	StatusStopped = iota
+	StatusCheckWait
+	StatusCheck
+	StatusDownloadWait
+	StatusDownload
+	StatusSeedWait
+	StatusSeed
+)
+
+// Seed ratio modes from the rpc spec.
+const (
+	RatioGlobal = iota
+	RatioSingle
+	RatioUnlimited
+)
+
+var torrentFields = []string{
+	"id", "name", "hashString", "status", "error", "errorString",
+	"isFinished", "percentDone", "uploadRatio", "addedDate", "doneDate",
+	"secondsSeeding", "downloadDir", "labels", "trackers",
+	"seedRatioMode", "seedRatioLimit",
+}
+
+// Torrent has the torrent-get fields transmon knows about, those not asked
+// for are left zero.
+type Torrent struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	HashString     string    `json:"hashString"`
+	Status         int       `json:"status"`
+	Error          int       `json:"error"`
+	ErrorString    string    `json:"errorString"`
+	IsFinished     bool      `json:"isFinished"`
+	PercentDone    float64   `json:"percentDone"`
+	UploadRatio    float64   `json:"uploadRatio"`
+	AddedDate      int64     `json:"addedDate"`
+	DoneDate       int64     `json:"doneDate"`
+	SecondsSeeding int64     `json:"secondsSeeding"`
+	DownloadDir    string    `json:"downloadDir"`
+	Labels         []string  `json:"labels"`
+	Trackers       []Tracker `json:"trackers"`
+	SeedRatioMode  int       `json:"seedRatioMode"`
+	SeedRatioLimit float64   `json:"seedRatioLimit"`
+	TotalSize      int64     `json:"totalSize"`
+	LeftUntilDone  int64     `json:"leftUntilDone"`
+	RateDownload   int64     `json:"rateDownload"`
+	RateUpload     int64     `json:"rateUpload"`
+	QueuePosition  int       `json:"queuePosition"`
+	ETA            int64     `json:"eta"`
+}
+
+type Tracker struct {
+	Announce string `json:"announce"`
+}
+
+func (t *Torrent) Added() time.Time {
+	return time.Unix(t.AddedDate, 0)
+}
+
+func (t *Torrent) Complete() bool {
+	return t.PercentDone >= 1
+}
+
+// seedLimits is the global seed ratio limit from session-get.
+type seedLimits struct {
+	Limited bool    `json:"seedRatioLimited"`
+	Limit   float64 `json:"seedRatioLimit"`
+}
+
+func (r *RawClient) seedLimits() (*seedLimits, error) {
+	s := new(seedLimits)
+	return s, r.call("session-get", Args{"fields": []string{"seedRatioLimited", "seedRatioLimit"}}, s)
+}
+
+// TorrentGet returns the torrents with ids, or every torrent when there are
+// none. Only fields are filled in, the cleaner's fields when none are given.
+func (r *RawClient) TorrentGet(fields []string, ids ...int) ([]*Torrent, error) {
+	if len(fields) < 1 {
+		fields = torrentFields
+	}
+	args := Args{"fields": fields}
+	if len(ids) > 0 {
+		args["ids"] = ids
+	}
+	var out struct {
+		Torrents []*Torrent `json:"torrents"`
+	}
+	if er := r.call("torrent-get", args, &out); er != nil {
+		return nil, er
+	}
+	if out.Torrents == nil {
+		return nil, errors.New("torrent-get response has no torrents")
+	}
+	return out.Torrents, nil
+}
+
+// TorrentSet changes the settings of torrents ids, keyed by their rpc names.
+func (r *RawClient) TorrentSet(ids []int, args Args) error {
+	a := Args{"ids": ids}
+	for k, v := range args {
+		a[k] = v
+	}
+	return r.call("torrent-set", a, nil)
+}
+
+func (r *RawClient) TorrentStart(ids ...int) error {
+	return r.call("torrent-start", Args{"ids": ids}, nil)
+}
+
+func (r *RawClient) TorrentStop(ids ...int) error {
+	return r.call("torrent-stop", Args{"ids": ids}, nil)
+}
+
+// TorrentRemove removes torrents ids, and their files when data is set.
+func (r *RawClient) TorrentRemove(data bool, ids ...int) error {
+	return r.call("torrent-remove", Args{"ids": ids, "delete-local-data": data}, nil)
+}
+
+// TorrentSetLocation points torrents ids at location, moving their files
+// there when move is set.
+func (r *RawClient) TorrentSetLocation(location string, move bool, ids ...int) error {
+	return r.call("torrent-set-location", Args{"ids": ids, "location": location, "move": move}, nil)
+}
+
+// Renamed is the result of torrent-rename-path.
+type Renamed struct {
+	ID   int    `json:"id"`
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+// TorrentRenamePath renames path, a file or folder of torrent id, to name.
+func (r *RawClient) TorrentRenamePath(id int, path, name string) (*Renamed, error) {
+	out := new(Renamed)
+	return out, r.call("torrent-rename-path", Args{"ids": []int{id}, "path": path, "name": name}, out)
+}
+
+func (r *RawClient) QueueMoveTop(ids ...int) error {
+	return r.call("queue-move-top", Args{"ids": ids}, nil)
+}
+
+func (r *RawClient) QueueMoveUp(ids ...int) error {
+	return r.call("queue-move-up", Args{"ids": ids}, nil)
+}
+
+func (r *RawClient) QueueMoveDown(ids ...int) error {
+	return r.call("queue-move-down", Args{"ids": ids}, nil)
+}
+
+func (r *RawClient) QueueMoveBottom(ids ...int) error {
+	return r.call("queue-move-bottom", Args{"ids": ids}, nil)
+}
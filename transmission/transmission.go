@@ -1,187 +1,185 @@
 package transmission
 
 import (
-	"crypto/md5"
-	"encoding/json"
-	"errors"
-	"io/ioutil"
-	"os"
+	"fmt"
+	"strings"
 	"time"
 
-	"github.com/albertrdixon/gearbox/logger"
-	"github.com/albertrdixon/gearbox/util"
-	"github.com/bitly/go-simplejson"
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/logging"
+	"github.com/albertrdixon/transmon/metrics"
 	"github.com/cenkalti/backoff"
-	"github.com/tubbebubbe/transmission"
 )
 
+var (
+	logger   = logging.New("transmission")
+	cleanLog = logging.New("cleaner")
+)
+
+// CheckPort is PortTest, logging failures as a closed port.
 func (r *RawClient) CheckPort() bool {
-	var (
-		open    bool
-		resp    = new(response)
-		req, _  = newRequest("port-test")
-		body, _ = json.Marshal(req)
-		out, er = r.Post(string(body))
-	)
+	open, er := r.PortTest()
 	if er != nil {
 		logger.Warnf("Failed to check port: %v", er)
-		return false
-	}
-	if er := json.Unmarshal(out, resp); er != nil {
-		logger.Warnf("Failed to check port: %v", er)
-		return false
-	}
-
-	arg := resp.Args["port-is-open"]
-	if er := json.Unmarshal(*arg, &open); er != nil {
-		logger.Warnf("Failed to check port: %v", er)
-		return false
 	}
 	return open
 }
 
 func (r *RawClient) UpdatePort(port int) error {
-	req, tag := newRequest("session-set",
-		"peer-port", port,
-		"port-forwarding-enabled", true,
-		"peer-port-random-on-start", false,
-	)
-
-	logger.Debugf("Encoding %v", req)
-	body, er := json.Marshal(req)
-	if er != nil {
-		return er
-	}
 	logger.Debugf("Requesting transmission peer port update to %d", port)
-	out, er := r.Post(string(body))
+	er := r.SessionSet(Args{
+		config.PortKey:    port,
+		config.ForwardKey: true,
+		config.RandomKey:  false,
+	})
 	if er != nil {
 		return er
 	}
-
-	response := new(response)
-	if er := json.Unmarshal(out, response); er != nil {
-		return er
-	}
-	if response.Tag != tag {
-		return errors.New("Request and response tags do not match")
-	}
-	if response.Result != "success" {
-		return errors.New(response.Result)
-	}
-	logger.Infof("Peer port updated to %d", port)
+	logger.Event("peer_port_updated", logging.Fields{"port": port}).Infof("Peer port updated to %d", port)
 	return nil
 }
 
-func UpdateSettings(path, ip string, port int) error {
-	logger.Infof("Updating transmission settings. bind-ip=%s port=%d", ip, port)
-	data, er := ioutil.ReadFile(path)
-	if er != nil {
-		return er
-	}
+// UpdateSettings writes settings into Transmission's settings file and binds
+// it to ip and port.
+func UpdateSettings(f *SettingsFile, ip string, port int, settings Args) error {
+	logger.Event("settings_updated", logging.Fields{"ip": ip, "port": port, "settings": len(settings)}).
+		Infof("Updating transmission settings. bind-ip=%s port=%d", ip, port)
+	s := make(Args, len(settings)+4)
+	for k, v := range settings {
+		s[k] = v
+	}
+	s[config.BindKey], s[config.PortKey], s[config.ForwardKey], s[config.RandomKey] = ip, port, true, false
+	return f.Set(s)
+}
 
-	s, er := simplejson.NewJson(data)
+// CleanTorrents applies the first matching rule to every torrent and returns
+// what it decided. With dryRun set decisions are only logged and the state
+// is kept in memory.
+func (c *Client) CleanTorrents(rules []*config.CleanerRule, dryRun bool) ([]*Decision, error) {
+	cleanLog.Event("cleaner_started", nil).Infof("Running torrent cleaner")
+	torrents, er := c.raw.TorrentGet(nil)
 	if er != nil {
-		return er
+		return nil, er
 	}
-
-	s.Set(bindKey, ip)
-	s.Set(portKey, port)
-	s.Set(forwardKey, true)
-	s.Set(randomKey, false)
-
-	data, er = s.Encode()
+	limits, er := c.raw.seedLimits()
 	if er != nil {
-		return er
+		cleanLog.Warnf("Failed to get session seed ratio limit: %v", er)
 	}
 
-	logger.Debugf("Writing updated transmission settings")
-	info, _ := os.Stat(path)
-	return ioutil.WriteFile(path, data, info.Mode().Perm())
-}
-
-func (c *Client) CleanTorrents() error {
-	logger.Infof("Running torrent cleaner")
-	torrents, er := c.GetTorrents()
-	if er != nil {
-		return er
-	}
-
-	torrents.SortByID(false)
-	logger.Infof("Found %d torrents to process", len(torrents))
+	cleanLog.Event("torrents_found", logging.Fields{"torrents": len(torrents)}).Infof("Found %d torrents to process", len(torrents))
+	var (
+		now      = time.Now()
+		previous = c.loadState()
+		current  = make(map[string]*record, len(torrents))
+		statuses = make([]*torrentStatus, 0, len(torrents))
+	)
 	for _, t := range torrents {
-		logger.Debugf("[Torrent %d: %q] Checking status", t.ID, t.Name)
-		id := util.Hashf(md5.New(), t.ID, t.Name)
-		status := &torrentStatus{Torrent: t, id: id, failures: 0}
-		status.setFailures()
-
-		if st, ok := seen[id]; ok {
-			status.failures = status.failures + st.failures
-			if !updated(st.Torrent, status.Torrent) {
-				status.failures++
+		log := cleanLog.With(torrentLog(t.ID, t.HashString, t.Name))
+		log.Event("torrent_checked", nil).Debugf("Checking %q", t.Name)
+		status := &torrentStatus{Torrent: t, id: t.HashString, firstSeen: now}
+		if r, ok := previous[status.id]; ok {
+			status.firstSeen = r.FirstSeen
+			if !updated(r, t) {
+				status.stalled = r.Stalled + 1
 			}
-		}
-
-		seen[id] = status
-		logger.Debugf("[Torrent %d: %q] Failures: %d", t.ID, t.Name, status.failures)
-	}
-
-	b := backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = 15 * time.Second
-	remove := make([]*torrentStatus, 0, 1)
-	for _, t := range seen {
-		if t.failed() {
-			b.Reset()
-			logger.Infof("[Torrent %d: %q] Removing", t.ID, t.Name)
-			er := backoff.RetryNotify(delTorrent(c, t.Torrent), b, func(e error, w time.Duration) {
-				logger.Errorf("[Torrent %d: %q] Failed to remove (retry in %v): %v", t.ID, t.Name, w, e)
-			})
-			if er == nil {
-				remove = append(remove, t)
-			} else {
-				logger.Errorf("[Torrent %d: %q] Failed to remove, will retry next cycle", t.ID, t.Name)
+			if t.Error != 0 {
+				status.errored = r.Errored + 1
 			}
+		} else if t.Error != 0 {
+			status.errored = 1
+		}
+		if t.Error != 0 {
+			log.Event("torrent_error", logging.Fields{"error": t.ErrorString}).Warnf("%q has an error: %s", t.Name, t.ErrorString)
+		}
+		current[status.id] = newRecord(status)
+		statuses = append(statuses, status)
+		log.Event("torrent_status", logging.Fields{"stalled": status.stalled, "errored": status.errored}).
+			Debugf("%q stalled: %d errored: %d", t.Name, status.stalled, status.errored)
+	}
+	defer c.saveState(current, dryRun)
+
+	decisions := make([]*Decision, 0)
+	for _, st := range statuses {
+		d := decide(rules, st, limits, now)
+		if d == nil {
+			continue
+		}
+		decisions = append(decisions, d)
+		log := cleanLog.Event("torrent_"+strings.Replace(d.Action, "-", "_", -1), d.fields())
+		if d.Action == "keep" {
+			log.Debugf("Decided to %s", d.message())
+			continue
+		}
+		if dryRun {
+			log.With(logging.Fields{"dry_run": true}).Infof("Would %s", d.message())
+			continue
 		}
-	}
 
-	for i := range remove {
-		delete(seen, remove[i].id)
+		log.Infof("Decided to %s", d.message())
+		if er := c.apply(d, st); er != nil {
+			log.Event("torrent_action_failed", nil).Errorf("Failed to %s %q, will retry next cycle: %v", d.Action, d.Name, er)
+			continue
+		}
+		d.Applied = true
+		if d.removes() {
+			metrics.TorrentsRemoved.With(d.Rule).Inc()
+			delete(current, st.id)
+		}
 	}
-	return nil
+	return decisions, nil
 }
 
-func (s *torrentStatus) setFailures() {
-	switch {
-	case s.Error != 0:
-		logger.Warnf("[Torrent %d: %q] Error: %s", s.ID, s.Name, s.ErrorString)
-		s.failures++
-	case s.IsFinished:
-		logger.Infof("[Torrent %d: %q] Finished", s.ID, s.Name)
-		s.failures = 3
-	}
+func (c *Client) apply(d *Decision, st *torrentStatus) error {
+	switch d.Action {
+	case config.ActionRemove, config.ActionRemoveData:
+		b := backoff.NewExponentialBackOff()
+		b.MaxElapsedTime = 15 * time.Second
+		return backoff.RetryNotify(delTorrent(c, d.ID, d.Action == config.ActionRemoveData), b, func(e error, w time.Duration) {
+			cleanLog.Event("torrent_remove_failed", logging.Fields{"retry": w}).With(d.fields()).
+				Errorf("Failed to remove %q (retry in %v): %v", d.Name, w, e)
+		})
+	case config.ActionStop:
+		return c.raw.TorrentStop(d.ID)
+	case config.ActionMove:
+		return c.raw.TorrentSetLocation(d.rule.Location, true, d.ID)
+	case config.ActionLabel:
+		return c.raw.TorrentSet([]int{d.ID}, Args{"labels": append(append([]string(nil), st.Labels...), d.rule.Label)})
+	}
+	return fmt.Errorf("unknown action %q", d.Action)
 }
 
-func (s *torrentStatus) failed() bool {
-	return s.failures >= 3
+// loadState returns the records of the previous run, from StateFile when one
+// is set and from memory otherwise or after a dry run.
+func (c *Client) loadState() map[string]*record {
+	if c.StateFile == "" || unsaved {
+		return seen
+	}
+	state, er := loadState(c.StateFile)
+	if er != nil {
+		cleanLog.Warnf("Failed to read cleaner state from %q, using last known state: %v", c.StateFile, er)
+		return seen
+	}
+	return state
 }
 
-func delTorrent(c *Client, t transmission.Torrent) backoff.Operation {
-	return func() (e error) {
-		del, er := transmission.NewDelCmd(t.ID, true)
-		if er != nil {
-			return er
-		}
-		_, e = c.ExecuteCommand(del)
+// saveState keeps state for the next run. A dry run only keeps it in memory
+// so its counts move on like a real run's without touching StateFile.
+func (c *Client) saveState(state map[string]*record, dryRun bool) {
+	seen, unsaved = state, dryRun
+	if c.StateFile == "" || dryRun {
 		return
 	}
+	if er := saveState(c.StateFile, state); er != nil {
+		cleanLog.Warnf("Failed to write cleaner state to %q: %v", c.StateFile, er)
+	}
 }
 
-func updated(a, b transmission.Torrent) bool {
-	return a.PercentDone != b.PercentDone || a.UploadRatio != b.UploadRatio
+func delTorrent(c *Client, id int, data bool) backoff.Operation {
+	return func() error {
+		return c.raw.TorrentRemove(data, id)
+	}
 }
 
-const (
-	bindKey    = "bind-address-ipv4"
-	portKey    = "peer-port"
-	forwardKey = "port-forwarding-enabled"
-	randomKey  = "peer-port-random-on-start"
-)
+func updated(r *record, t *Torrent) bool {
+	return r.PercentDone != t.PercentDone || r.UploadRatio != t.UploadRatio
+}
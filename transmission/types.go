@@ -3,82 +3,50 @@ package transmission
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/tubbebubbe/transmission"
 )
 
-var seen map[string]*torrentStatus
-
-type RawClient struct {
-	transmission.ApiClient
-}
+var (
+	seen map[string]*record
+	// unsaved is set while seen holds dry run counts the state file lacks.
+	unsaved bool
+)
 
 type Client struct {
 	transmission.TransmissionClient
+	StateFile string
+	raw       *RawClient
 }
 
 type torrentStatus struct {
-	transmission.Torrent
-	id       string
-	failures int
+	*Torrent
+	id               string
+	stalled, errored int
+	firstSeen        time.Time
 }
 
 type request struct {
-	Method string  `json:"method"`
-	Tag    int     `json:"tag,omitempty"`
-	Args   argList `json:"arguments,omitempty"`
+	Method string      `json:"method"`
+	Tag    int64       `json:"tag"`
+	Args   interface{} `json:"arguments,omitempty"`
 }
 
 type response struct {
-	Result string  `json:"result"`
-	Tag    int     `json:"tag,omitempty"`
-	Args   argList `json:"arguments,omitempty"`
+	Result string          `json:"result"`
+	Tag    int64           `json:"tag"`
+	Args   json.RawMessage `json:"arguments,omitempty"`
 }
 
-type argList map[string]*json.RawMessage
-
 func (r *request) String() string {
 	return fmt.Sprintf("Request(method=%q, tag=%d, args=%v)", r.Method, r.Tag, r.Args)
 }
 
-func (a argList) String() string {
-	list := make([]string, 0, len(a))
-	for k, v := range a {
-		list = append(list, fmt.Sprintf("%q: %v", k, string([]byte(*v))))
-	}
-	return fmt.Sprintf("{%s}", strings.Join(list, ", "))
-}
-
-func NewRawClient(url, user, pass string) *RawClient {
-	return &RawClient{ApiClient: transmission.NewClient(url, user, pass)}
-}
-
 func NewClient(url, user, pass string) *Client {
-	return &Client{TransmissionClient: transmission.New(url, user, pass)}
-}
-
-func newRequest(method string, args ...interface{}) (*request, int) {
-	c := &request{
-		Method: method,
-		Tag:    tag(),
-		Args:   make(map[string]*json.RawMessage),
-	}
-	if !even(len(args)) {
-		return c, c.Tag
-	}
-	for i := range args {
-		s, ok := args[i].(string)
-		if ok && even(i) {
-			if out, er := json.Marshal(args[i+1]); er == nil {
-				jr := json.RawMessage(out)
-				c.Args[s] = &jr
-			}
-		}
-	}
-	return c, c.Tag
+	return &Client{TransmissionClient: transmission.New(url, user, pass), raw: NewRawClient(url, user, pass)}
 }
 
 func init() {
-	seen = make(map[string]*torrentStatus)
+	seen = make(map[string]*record)
 }
@@ -0,0 +1,48 @@
+package vpn
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"golang.org/x/net/context"
+)
+
+// Backend runs the tunnel Transmission is bound to.
+type Backend interface {
+	Name() string
+	Start(ctx context.Context) error
+	Stop() error
+	Status() *Status
+	IP() (string, error)
+}
+
+// Status is a snapshot of a Backend. PID is -1 for tunnels without a
+// process of their own.
+type Status struct {
+	Running bool
+	PID     int
+	Started time.Time
+	State   string
+}
+
+// New returns the backend named by c.VPN.
+func New(c *config.Config) (Backend, error) {
+	switch c.VPN {
+	case config.OpenVPNTunnel:
+		if c.PIA.OpenVPN != nil {
+			if er := writePIA(c); er != nil {
+				return nil, er
+			}
+		}
+		o := c.OpenVPN
+		return NewOpenVPN(baseCommand(c), o.Management, o.Tun, c.Timeout.Duration), nil
+	case config.WireGuardTunnel:
+		wg := c.WireGuard
+		if wg == nil || wg.Config == "" {
+			return nil, fmt.Errorf("vpn %q needs wireguard.config", c.VPN)
+		}
+		return NewWireGuard(wg.Config, wg.Device, wg.Mode), nil
+	}
+	return nil, fmt.Errorf("Unknown vpn %q", c.VPN)
+}
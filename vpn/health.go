@@ -0,0 +1,137 @@
+package vpn
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Checker probes connectivity through the tunnel device. Ping, URL and
+// HomeIP are each skipped when empty.
+type Checker struct {
+	Device  string
+	Ping    string
+	URL     string
+	IPURL   string
+	HomeIP  string
+	Timeout time.Duration
+}
+
+// Check returns the first probe that failed.
+func (c *Checker) Check() error {
+	ip, er := FindIP(c.Device)
+	if er != nil {
+		return er
+	}
+	return c.check(ip)
+}
+
+func (c *Checker) check(ip string) error {
+	if c.Ping != "" {
+		if er := ping(c.Device, c.Ping, c.Timeout); er != nil {
+			return er
+		}
+	}
+	if c.URL != "" {
+		if er := probe(c.URL, ip, c.Timeout); er != nil {
+			return er
+		}
+	}
+	if c.HomeIP != "" {
+		public, er := PublicIP(c.IPURL, ip, c.Timeout)
+		if er != nil {
+			return er
+		}
+		if public == c.HomeIP {
+			return fmt.Errorf("Public ip through %s is the home ip %s", c.Device, public)
+		}
+		logger.Debugf("Public ip through %s: %s", c.Device, public)
+	}
+	return nil
+}
+
+// PublicIP asks url, a service answering with the caller's address in plain
+// text, which address requests from the local address ip come from.
+func PublicIP(url, ip string, timeout time.Duration) (string, error) {
+	resp, er := boundClient(ip, timeout).Get(url)
+	if er != nil {
+		return "", er
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Public ip lookup %s: %s", url, resp.Status)
+	}
+
+	body, er := ioutil.ReadAll(io.LimitReader(resp.Body, 64))
+	if er != nil {
+		return "", er
+	}
+	public := strings.TrimSpace(string(body))
+	if net.ParseIP(public) == nil {
+		return "", fmt.Errorf("Public ip lookup %s: bad answer %q", url, public)
+	}
+	return public, nil
+}
+
+// Leak looks up the public ip through the tunnel address ip and through the
+// default route. They only match when traffic bypasses the VPN.
+func Leak(url, ip string, timeout time.Duration) (bool, string, error) {
+	tunnel, er := PublicIP(url, ip, timeout)
+	if er != nil {
+		return false, "", er
+	}
+	direct, er := PublicIP(url, "", timeout)
+	if er != nil {
+		return false, "", er
+	}
+	logger.Debugf("Public ip through tunnel: %s, through default route: %s", tunnel, direct)
+	return tunnel == direct, direct, nil
+}
+
+func probe(url, ip string, timeout time.Duration) error {
+	resp, er := boundClient(ip, timeout).Get(url)
+	if er != nil {
+		return er
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("HTTP check %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+func ping(dev, host string, timeout time.Duration) error {
+	wait := int(timeout / time.Second)
+	if wait < 1 {
+		wait = 1
+	}
+	out, er := exec.Command("ping", "-c", "1", "-W", strconv.Itoa(wait), "-I", dev, host).CombinedOutput()
+	if er != nil {
+		return fmt.Errorf("Ping %s via %s: %v: %s", host, dev, er, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// boundClient makes its requests from the local address ip so they can only
+// leave through the interface that owns it. Without ip requests follow the
+// default route.
+func boundClient(ip string, timeout time.Duration) *http.Client {
+	d := &net.Dialer{Timeout: timeout}
+	if ip != "" {
+		d.LocalAddr = &net.TCPAddr{IP: net.ParseIP(ip)}
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Dial:                d.Dial,
+			TLSHandshakeTimeout: timeout,
+			DisableKeepAlives:   true,
+		},
+	}
+}
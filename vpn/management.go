@@ -0,0 +1,277 @@
+package vpn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/albertrdixon/transmon/metrics"
+)
+
+// OpenVPN states reported by the management interface.
+const (
+	StateConnecting   = "CONNECTING"
+	StateConnected    = "CONNECTED"
+	StateReconnecting = "RECONNECTING"
+	StateExiting      = "EXITING"
+)
+
+var ErrNotConnected = errors.New("Not connected to the openvpn management interface")
+
+type State struct {
+	Time     time.Time
+	Name     string
+	Desc     string
+	LocalIP  string
+	RemoteIP string
+}
+
+func (s *State) String() string {
+	if s.LocalIP == "" {
+		return fmt.Sprintf("%s (%s)", s.Name, s.Desc)
+	}
+	return fmt.Sprintf("%s (%s) local=%s remote=%s", s.Name, s.Desc, s.LocalIP, s.RemoteIP)
+}
+
+// Management follows openvpn through its management interface on a unix
+// socket. It reconnects whenever openvpn restarts until it is closed.
+type Management struct {
+	Path    string
+	Timeout time.Duration
+
+	cmd     sync.Mutex
+	mu      sync.RWMutex
+	conn    net.Conn
+	current *State
+	in, out int64
+
+	replies chan string
+	states  chan *State
+	done    chan struct{}
+}
+
+func NewManagement(path string, timeout time.Duration) *Management {
+	m := &Management{
+		Path:    path,
+		Timeout: timeout,
+		replies: make(chan string, 64),
+		states:  make(chan *State, 8),
+		done:    make(chan struct{}),
+	}
+	go m.run()
+	return m
+}
+
+// States delivers state changes. When nobody reads them the oldest are
+// dropped.
+func (m *Management) States() <-chan *State {
+	return m.states
+}
+
+// State is the last state openvpn reported, nil until the first one.
+func (m *Management) State() *State {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current
+}
+
+// Bytes is the traffic through the tunnel since it connected.
+func (m *Management) Bytes() (in, out int64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.in, m.out
+}
+
+// Signal has openvpn raise sig on itself. SIGHUP restarts the tunnel in
+// place. The current state is cleared so waiting for the next CONNECTED
+// does not see the old one.
+func (m *Management) Signal(sig string) error {
+	m.mu.Lock()
+	m.current = nil
+	m.mu.Unlock()
+	_, er := m.command("signal " + sig)
+	return er
+}
+
+func (m *Management) Close() {
+	close(m.done)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn != nil {
+		m.conn.Close()
+	}
+}
+
+func (m *Management) run() {
+	for {
+		conn, er := net.DialTimeout("unix", m.Path, m.Timeout)
+		if er == nil {
+			logger.Debugf("Connected to openvpn management at %s", m.Path)
+			m.serve(conn)
+		}
+		select {
+		case <-m.done:
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (m *Management) serve(conn net.Conn) {
+	m.mu.Lock()
+	select {
+	case <-m.done:
+		m.mu.Unlock()
+		conn.Close()
+		return
+	default:
+	}
+	m.conn = conn
+	m.mu.Unlock()
+
+	go m.setup()
+	s := bufio.NewScanner(conn)
+	for s.Scan() {
+		m.handle(strings.TrimRight(s.Text(), "\r"))
+	}
+
+	m.mu.Lock()
+	m.conn = nil
+	m.mu.Unlock()
+	conn.Close()
+	logger.Debugf("Lost openvpn management connection: %v", s.Err())
+}
+
+// setup turns on real time notifications and reports the state openvpn is
+// already in.
+func (m *Management) setup() {
+	for _, cmd := range []string{"state on", "bytecount 5"} {
+		if _, er := m.command(cmd); er != nil {
+			logger.Warnf("openvpn management %q: %v", cmd, er)
+			return
+		}
+	}
+	lines, er := m.command("state")
+	if er != nil || len(lines) < 1 {
+		return
+	}
+	if st, er := parseState(lines[len(lines)-1]); er == nil {
+		m.setState(st)
+	}
+}
+
+func (m *Management) handle(line string) {
+	switch {
+	case strings.HasPrefix(line, ">STATE:"):
+		st, er := parseState(strings.TrimPrefix(line, ">STATE:"))
+		if er != nil {
+			logger.Debugf("%v", er)
+			return
+		}
+		m.setState(st)
+	case strings.HasPrefix(line, ">BYTECOUNT:"):
+		var in, out int64
+		if _, er := fmt.Sscanf(strings.TrimPrefix(line, ">BYTECOUNT:"), "%d,%d", &in, &out); er != nil {
+			return
+		}
+		m.mu.Lock()
+		m.in, m.out = in, out
+		m.mu.Unlock()
+		metrics.VPNBytes.With("in").Set(float64(in))
+		metrics.VPNBytes.With("out").Set(float64(out))
+	case strings.HasPrefix(line, ">"):
+		logger.Debugf("openvpn management: %s", line)
+	default:
+		select {
+		case m.replies <- line:
+		default:
+		}
+	}
+}
+
+// setState records st unless a newer state already came in, the reply to
+// setup's state command can arrive after real time notifications.
+func (m *Management) setState(st *State) {
+	m.mu.Lock()
+	if m.current != nil && st.Time.Before(m.current.Time) {
+		m.mu.Unlock()
+		return
+	}
+	m.current = st
+	m.mu.Unlock()
+	for {
+		select {
+		case m.states <- st:
+			return
+		default:
+		}
+		select {
+		case <-m.states:
+		default:
+		}
+	}
+}
+
+// command sends cmd and collects the reply up to its SUCCESS, ERROR or END
+// line.
+func (m *Management) command(cmd string) ([]string, error) {
+	m.cmd.Lock()
+	defer m.cmd.Unlock()
+
+	m.mu.RLock()
+	conn := m.conn
+	m.mu.RUnlock()
+	if conn == nil {
+		return nil, ErrNotConnected
+	}
+	for len(m.replies) > 0 {
+		<-m.replies
+	}
+	if _, er := fmt.Fprintf(conn, "%s\n", cmd); er != nil {
+		return nil, er
+	}
+
+	var (
+		lines   []string
+		timeout = time.After(m.Timeout)
+	)
+	for {
+		select {
+		case l := <-m.replies:
+			switch {
+			case strings.HasPrefix(l, "SUCCESS:"), l == "END":
+				return lines, nil
+			case strings.HasPrefix(l, "ERROR:"):
+				return nil, errors.New(strings.TrimSpace(strings.TrimPrefix(l, "ERROR:")))
+			}
+			lines = append(lines, l)
+		case <-timeout:
+			return nil, fmt.Errorf("openvpn management %q timed out", cmd)
+		}
+	}
+}
+
+// parseState reads time,name,description,local ip,remote ip[,...].
+func parseState(s string) (*State, error) {
+	f := strings.Split(s, ",")
+	if len(f) < 3 {
+		return nil, fmt.Errorf("Bad openvpn state %q", s)
+	}
+	ts, er := strconv.ParseInt(f[0], 10, 64)
+	if er != nil {
+		return nil, fmt.Errorf("Bad openvpn state %q", s)
+	}
+	st := &State{Time: time.Unix(ts, 0), Name: f[1], Desc: f[2]}
+	if len(f) > 3 {
+		st.LocalIP = f[3]
+	}
+	if len(f) > 4 {
+		st.RemoteIP = f[4]
+	}
+	return st, nil
+}
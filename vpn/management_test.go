@@ -0,0 +1,105 @@
+package vpn
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOpenVPN answers management commands like openvpn does and reports a
+// connection once notifications are on.
+func fakeOpenVPN(t *testing.T, l net.Listener, signals chan<- string) {
+	conn, er := l.Accept()
+	if er != nil {
+		return
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, ">INFO:OpenVPN Management Interface Version 1\r\n")
+
+	s := bufio.NewScanner(conn)
+	for s.Scan() {
+		switch cmd := s.Text(); cmd {
+		case "state on":
+			fmt.Fprint(conn, "SUCCESS: real-time state notification set to ON\r\n")
+		case "bytecount 5":
+			fmt.Fprint(conn, "SUCCESS: bytecount interval changed\r\n")
+		case "state":
+			fmt.Fprint(conn, "1500000000,CONNECTING,,,\r\nEND\r\n")
+			fmt.Fprint(conn, ">STATE:1500000005,CONNECTED,SUCCESS,10.8.0.6,1.2.3.4,1198,,\r\n")
+			fmt.Fprint(conn, ">BYTECOUNT:100,200\r\n")
+		case "signal SIGHUP":
+			fmt.Fprint(conn, "SUCCESS: signal SIGHUP thrown\r\n")
+			signals <- cmd
+		default:
+			fmt.Fprintf(conn, "ERROR: unknown command [%s], enter 'help' for more options\r\n", cmd)
+		}
+	}
+}
+
+func TestManagement(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+
+	sock := filepath.Join(dir, "openvpn.sock")
+	l, er := net.Listen("unix", sock)
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer l.Close()
+	signals := make(chan string, 1)
+	go fakeOpenVPN(t, l, signals)
+
+	m := NewManagement(sock, 2*time.Second)
+	defer m.Close()
+
+	next := func() *State {
+		select {
+		case s := <-m.States():
+			return s
+		case <-time.After(3 * time.Second):
+			t.Fatal("no state from management interface")
+		}
+		return nil
+	}
+	s := next()
+	if s.Name == StateConnecting {
+		s = next()
+	}
+	is.Equal(StateConnected, s.Name)
+	is.Equal("10.8.0.6", s.LocalIP)
+	is.Equal("1.2.3.4", s.RemoteIP)
+	is.Equal(s, m.State())
+
+	time.Sleep(50 * time.Millisecond)
+	in, out := m.Bytes()
+	is.EqualValues(100, in)
+	is.EqualValues(200, out)
+
+	is.NoError(m.Signal("SIGHUP"))
+	is.Equal("signal SIGHUP", <-signals)
+	is.Nil(m.State())
+	is.Error(m.Signal("SIGBOGUS"))
+}
+
+func TestParseState(t *testing.T) {
+	is := assert.New(t)
+	s, er := parseState("1500000000,RECONNECTING,ping-restart,,,,,")
+	is.NoError(er)
+	is.Equal(StateReconnecting, s.Name)
+	is.Equal("ping-restart", s.Desc)
+	is.Empty(s.LocalIP)
+
+	_, er = parseState("CONNECTED")
+	is.Error(er)
+}
@@ -0,0 +1,127 @@
+package vpn
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/albertrdixon/transmon/supervise"
+	"golang.org/x/net/context"
+)
+
+// stopTimeout is how long openvpn gets to exit after SIGTERM.
+const stopTimeout = 10 * time.Second
+
+// OpenVPN runs openvpn as a supervised child. With a management socket it
+// is followed through its management interface.
+type OpenVPN struct {
+	Command    string
+	Management string
+	Device     string
+	Timeout    time.Duration
+
+	mu   sync.Mutex
+	proc *supervise.Process
+	mgmt *Management
+}
+
+func NewOpenVPN(command, management, device string, timeout time.Duration) *OpenVPN {
+	return &OpenVPN{
+		Command:    OpenVPNCommand(command, management),
+		Management: management,
+		Device:     device,
+		Timeout:    timeout,
+	}
+}
+
+// OpenVPNCommand is command with the management socket added when one is
+// given.
+func OpenVPNCommand(command, management string) string {
+	if management != "" && !strings.Contains(command, "--management ") {
+		command += " --management " + management + " unix"
+	}
+	return command
+}
+
+func (o *OpenVPN) Name() string {
+	return "openvpn"
+}
+
+func (o *OpenVPN) Start(ctx context.Context) error {
+	if o.Management != "" {
+		os.Remove(o.Management)
+	}
+	p, er := supervise.New("openvpn", o.Command)
+	if er != nil {
+		return er
+	}
+
+	o.mu.Lock()
+	o.proc = p
+	if o.Management != "" {
+		o.mgmt = NewManagement(o.Management, o.Timeout)
+	}
+	o.mu.Unlock()
+	go p.Run(ctx)
+	return nil
+}
+
+// Stop waits for openvpn to exit without holding the lock, so Status keeps
+// answering meanwhile.
+func (o *OpenVPN) Stop() error {
+	o.mu.Lock()
+	p := o.proc
+	if o.mgmt != nil {
+		o.mgmt.Close()
+		o.mgmt = nil
+	}
+	o.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+
+	p.Stop(stopTimeout)
+	o.mu.Lock()
+	if o.proc == p {
+		o.proc = nil
+	}
+	o.mu.Unlock()
+	return nil
+}
+
+func (o *OpenVPN) Status() *Status {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	s := &Status{PID: -1}
+	if o.proc == nil {
+		return s
+	}
+	s.PID = o.proc.Pid()
+	s.Running = o.proc.Running()
+	s.Started = o.proc.Started
+	if o.mgmt != nil {
+		if st := o.mgmt.State(); st != nil {
+			s.State = st.Name
+		}
+	}
+	return s
+}
+
+// IP is the address openvpn reported on CONNECTED, or the one on Device
+// without a management socket.
+func (o *OpenVPN) IP() (string, error) {
+	if m := o.Manager(); m != nil {
+		if st := m.State(); st != nil && st.Name == StateConnected {
+			return st.LocalIP, nil
+		}
+	}
+	return FindIP(o.Device)
+}
+
+// Manager returns the management client, nil without a management socket.
+func (o *OpenVPN) Manager() *Management {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.mgmt
+}
@@ -0,0 +1,55 @@
+package vpn
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestOpenVPNStatusWhileStopping(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+	script := filepath.Join(dir, "openvpn.sh")
+	ioutil.WriteFile(script, []byte("trap '' TERM\nsleep 2\n"), 0755)
+
+	o := NewOpenVPN("sh "+script, "", "tun0", time.Second)
+	if !is.NoError(o.Start(context.Background())) {
+		t.FailNow()
+	}
+	for i := 0; i < 50 && !o.Status().Running; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	is.True(o.Status().Running)
+
+	stopped := make(chan struct{})
+	go func() {
+		o.Stop()
+		close(stopped)
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	status := make(chan *Status)
+	go func() { status <- o.Status() }()
+	select {
+	case s := <-status:
+		is.True(s.Running, "openvpn is still exiting")
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Status blocked while openvpn was stopping")
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not return")
+	}
+	is.Equal(-1, o.Status().PID)
+}
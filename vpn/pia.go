@@ -0,0 +1,61 @@
+package vpn
+
+import (
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/albertrdixon/transmon/pia"
+)
+
+// regionsTimeout bounds fetching the region list, the firewall and every
+// failover server wait on it.
+const regionsTimeout = 30 * time.Second
+
+// PIAOpenVPN is the openvpn config pia.openvpn asks for. The region list
+// comes from regions_url when one is set.
+func PIAOpenVPN(c *config.Config) (*pia.OpenVPN, error) {
+	p := c.PIA.OpenVPN
+	regions, er := pia.LoadRegions(p.RegionsURL, regionsTimeout)
+	if er != nil {
+		logger.Warnf("Using built in PIA regions, refresh from %s failed: %v", p.RegionsURL, er)
+	}
+	r, er := pia.FindRegion(regions, p.Region)
+	if er != nil {
+		return nil, er
+	}
+	if !r.PortForward && (c.Provider == "pia" || c.Provider == "pia-nextgen") {
+		logger.Warnf("PIA region %s does not support port forwarding", r.Name)
+	}
+	return &pia.OpenVPN{Region: r, Protocol: p.Protocol, Cipher: p.Cipher, Device: c.OpenVPN.Tun, CA: p.CA}, nil
+}
+
+// Command is the openvpn command line for c. With pia.openvpn it loads the
+// generated config, openvpn.command then only adds options.
+func Command(c *config.Config) string {
+	return OpenVPNCommand(baseCommand(c), c.OpenVPN.Management)
+}
+
+func baseCommand(c *config.Config) string {
+	cmd, p := c.OpenVPN.Command, c.PIA.OpenVPN
+	if p == nil {
+		return cmd
+	}
+	if cmd == "" {
+		cmd = "openvpn"
+	}
+	conf, _ := pia.Files(p.Dir)
+	return cmd + " --config " + conf
+}
+
+func writePIA(c *config.Config) error {
+	o, er := PIAOpenVPN(c)
+	if er != nil {
+		return er
+	}
+	conf, er := o.Write(c.PIA.OpenVPN.Dir, c.PIA.User, c.PIA.Pass)
+	if er != nil {
+		return er
+	}
+	logger.Infof("Wrote openvpn config for PIA %s to %s", o.Region.Name, conf)
+	return nil
+}
@@ -2,12 +2,16 @@ package vpn
 
 import (
 	"errors"
+	"fmt"
 	"net"
+	"os/exec"
 	"strings"
 
-	"github.com/albertrdixon/gearbox/logger"
+	"github.com/albertrdixon/transmon/logging"
 )
 
+var logger = logging.New("vpn")
+
 func FindIP(dev string) (string, error) {
 	logger.Debugf("Looking up ip for interface %q", dev)
 	inf, er := net.InterfaceByName(dev)
@@ -26,3 +30,24 @@ func FindIP(dev string) (string, error) {
 	bits := strings.SplitN(addrs[0].String(), "/", 2)
 	return bits[0], nil
 }
+
+// DefaultDevice is the interface the kernel sends internet traffic through,
+// policy routing included.
+func DefaultDevice() (string, error) {
+	out, er := exec.Command("ip", "-4", "route", "get", "1.1.1.1").Output()
+	if er != nil {
+		return "", fmt.Errorf("ip route get: %v", er)
+	}
+	return routeDevice(string(out))
+}
+
+// routeDevice reads the device from ip route get output.
+func routeDevice(route string) (string, error) {
+	fields := strings.Fields(route)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "dev" {
+			return fields[i+1], nil
+		}
+	}
+	return "", fmt.Errorf("No device in route %q", strings.TrimSpace(route))
+}
@@ -1,7 +1,12 @@
 package vpn
 
 import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,3 +18,52 @@ func TestFindIPFake(t *testing.T) {
 	is.Error(er)
 	is.Empty(ip)
 }
+
+func TestRouteDevice(t *testing.T) {
+	is := assert.New(t)
+	dev, er := routeDevice("1.1.1.1 dev wg0 table 51820 src 10.2.0.2 uid 0 \n    cache \n")
+	is.NoError(er)
+	is.Equal("wg0", dev)
+	dev, er = routeDevice("1.1.1.1 via 192.168.1.1 dev eth0 src 192.168.1.10 uid 0\n")
+	is.NoError(er)
+	is.Equal("eth0", dev)
+	_, er = routeDevice("RTNETLINK answers: Network is unreachable")
+	is.Error(er)
+}
+
+func TestChecker(t *testing.T) {
+	is := assert.New(t)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ip":
+			host, _, _ := net.SplitHostPort(r.RemoteAddr)
+			fmt.Fprintln(w, host)
+		case "/204":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer s.Close()
+
+	ip, er := PublicIP(s.URL+"/ip", "127.0.0.1", time.Second)
+	is.NoError(er)
+	is.Equal("127.0.0.1", ip)
+
+	c := &Checker{Device: "tun0", URL: s.URL + "/204", IPURL: s.URL + "/ip", HomeIP: "203.0.113.7", Timeout: time.Second}
+	is.NoError(c.check("127.0.0.1"))
+
+	c.HomeIP = "127.0.0.1"
+	is.Error(c.check("127.0.0.1"))
+
+	c.HomeIP, c.URL = "", s.URL+"/missing"
+	is.Error(c.check("127.0.0.1"))
+
+	leak, ip, er := Leak(s.URL+"/ip", "127.0.0.1", time.Second)
+	is.NoError(er)
+	is.True(leak)
+	is.Equal("127.0.0.1", ip)
+
+	_, _, er = Leak(s.URL+"/missing", "127.0.0.1", time.Second)
+	is.Error(er)
+}
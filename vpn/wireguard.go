@@ -0,0 +1,232 @@
+package vpn
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/albertrdixon/transmon/config"
+	"golang.org/x/net/context"
+)
+
+// wgTable routes traffic from the tunnel address in wg mode, the same table
+// wg-quick uses.
+const wgTable = 51820
+
+// wgQuickKeys are wg-quick extensions that wg setconf does not understand.
+var wgQuickKeys = map[string]bool{
+	"address": true, "dns": true, "mtu": true, "table": true, "saveconfig": true,
+	"preup": true, "postup": true, "predown": true, "postdown": true,
+}
+
+// WireGuard brings a WireGuard interface up and down. In wg-quick mode that
+// is wg-quick up/down. In wg mode the interface is set up with ip and wg
+// setconf, and only traffic from its addresses is routed through it.
+type WireGuard struct {
+	Config string
+	Device string
+	Mode   string
+
+	mu      sync.Mutex
+	started time.Time
+	run     func(args ...string) error
+	exists  func(dev string) bool
+}
+
+func NewWireGuard(conf, device, mode string) *WireGuard {
+	return &WireGuard{Config: conf, Device: device, Mode: mode, run: execute, exists: linkExists}
+}
+
+func (w *WireGuard) Name() string {
+	return "wireguard"
+}
+
+// Start brings the interface up, taking down one left behind by a crash
+// first since neither wg-quick nor ip link add accept an existing one.
+func (w *WireGuard) Start(ctx context.Context) error {
+	if w.exists(w.Device) {
+		logger.Warnf("%s is already up, taking it down first", w.Device)
+		w.Stop()
+	}
+	if w.Mode == config.WGQuick {
+		if er := w.run("wg-quick", "up", w.Config); er != nil {
+			return er
+		}
+	} else if er := w.setconf(); er != nil {
+		w.Stop()
+		return er
+	}
+
+	w.mu.Lock()
+	w.started = time.Now()
+	w.mu.Unlock()
+	return nil
+}
+
+func (w *WireGuard) setconf() error {
+	wg, er := ReadWireGuard(w.Config)
+	if er != nil {
+		return er
+	}
+	f, er := ioutil.TempFile("", "transmon-wg")
+	if er != nil {
+		return er
+	}
+	defer os.Remove(f.Name())
+	if _, er := f.Write(wg.Conf); er != nil {
+		f.Close()
+		return er
+	}
+	if er := f.Close(); er != nil {
+		return er
+	}
+
+	table := strconv.Itoa(wgTable)
+	cmds := [][]string{
+		{"ip", "link", "add", "dev", w.Device, "type", "wireguard"},
+		{"wg", "setconf", w.Device, f.Name()},
+	}
+	for _, a := range wg.Addresses {
+		cmds = append(cmds, []string{"ip", "address", "add", a, "dev", w.Device})
+	}
+	cmds = append(cmds, []string{"ip", "link", "set", "up", "dev", w.Device})
+	routed := make(map[string]bool)
+	for _, ip := range wg.IPs() {
+		if f := family(ip); !routed[f] {
+			routed[f] = true
+			cmds = append(cmds, []string{"ip", f, "route", "add", "default", "dev", w.Device, "table", table})
+		}
+	}
+	for _, ip := range wg.IPs() {
+		cmds = append(cmds, []string{"ip", family(ip), "rule", "add", "from", ip, "table", table})
+	}
+	for _, cmd := range cmds {
+		if er := w.run(cmd...); er != nil {
+			return er
+		}
+	}
+	return nil
+}
+
+func (w *WireGuard) Stop() error {
+	w.mu.Lock()
+	w.started = time.Time{}
+	w.mu.Unlock()
+	if w.Mode == config.WGQuick {
+		return w.run("wg-quick", "down", w.Config)
+	}
+
+	if wg, er := ReadWireGuard(w.Config); er == nil {
+		for _, ip := range wg.IPs() {
+			w.run("ip", family(ip), "rule", "del", "from", ip, "table", strconv.Itoa(wgTable))
+		}
+	}
+	return w.run("ip", "link", "del", "dev", w.Device)
+}
+
+func (w *WireGuard) Status() *Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := &Status{PID: -1, State: "down"}
+	if inf, er := net.InterfaceByName(w.Device); er == nil && inf.Flags&net.FlagUp != 0 {
+		s.Running, s.Started, s.State = true, w.started, "up"
+	}
+	return s
+}
+
+func (w *WireGuard) IP() (string, error) {
+	return FindIP(w.Device)
+}
+
+// family is the ip option for the address family of ip, rules and routes of
+// each family are separate.
+func family(ip string) string {
+	if strings.Contains(ip, ":") {
+		return "-6"
+	}
+	return "-4"
+}
+
+func linkExists(dev string) bool {
+	_, er := net.InterfaceByName(dev)
+	return er == nil
+}
+
+// WireGuardConfig is a wg-quick config file split into what wg setconf
+// takes and the interface addresses and peer endpoints.
+type WireGuardConfig struct {
+	Conf      []byte
+	Addresses []string
+	Endpoints []string
+}
+
+// IPs are the addresses without their prefix length.
+func (c *WireGuardConfig) IPs() []string {
+	ips := make([]string, 0, len(c.Addresses))
+	for _, a := range c.Addresses {
+		ips = append(ips, strings.SplitN(a, "/", 2)[0])
+	}
+	return ips
+}
+
+func ReadWireGuard(file string) (*WireGuardConfig, error) {
+	data, er := ioutil.ReadFile(file)
+	if er != nil {
+		return nil, er
+	}
+	return parseWireGuard(data)
+}
+
+func parseWireGuard(data []byte) (*WireGuardConfig, error) {
+	var (
+		c    = new(WireGuardConfig)
+		conf bytes.Buffer
+		s    = bufio.NewScanner(bytes.NewReader(data))
+	)
+	for s.Scan() {
+		line := s.Text()
+		kv := strings.SplitN(strings.SplitN(line, "#", 2)[0], "=", 2)
+		if len(kv) == 2 {
+			key, value := strings.ToLower(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+			switch key {
+			case "address":
+				for _, a := range strings.Split(value, ",") {
+					if a = strings.TrimSpace(a); a != "" {
+						c.Addresses = append(c.Addresses, a)
+					}
+				}
+			case "endpoint":
+				c.Endpoints = append(c.Endpoints, value)
+			}
+			if wgQuickKeys[key] {
+				continue
+			}
+		}
+		conf.WriteString(line + "\n")
+	}
+	if er := s.Err(); er != nil {
+		return nil, er
+	}
+	if len(c.Addresses) < 1 {
+		return nil, fmt.Errorf("WireGuard config has no Address")
+	}
+	c.Conf = conf.Bytes()
+	return c, nil
+}
+
+func execute(args ...string) error {
+	logger.Debugf("Running %s", strings.Join(args, " "))
+	out, er := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if er != nil {
+		return fmt.Errorf("%s: %v: %s", strings.Join(args, " "), er, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package vpn
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/albertrdixon/transmon/config"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+const wgConf = `[Interface]
+PrivateKey = cHJpdmF0ZQ==
+Address = 10.2.0.2/32, fd00::2/128
+DNS = 10.2.0.1
+PostUp = echo up # wg-quick only
+
+[Peer]
+PublicKey = cHVibGlj
+AllowedIPs = 0.0.0.0/0
+Endpoint = 1.2.3.4:51820
+`
+
+func TestParseWireGuard(t *testing.T) {
+	is := assert.New(t)
+	c, er := parseWireGuard([]byte(wgConf))
+	is.NoError(er)
+	is.Equal([]string{"10.2.0.2/32", "fd00::2/128"}, c.Addresses)
+	is.Equal([]string{"10.2.0.2", "fd00::2"}, c.IPs())
+	is.Equal([]string{"1.2.3.4:51820"}, c.Endpoints)
+
+	conf := string(c.Conf)
+	is.Contains(conf, "PrivateKey = cHJpdmF0ZQ==\n")
+	is.Contains(conf, "Endpoint = 1.2.3.4:51820\n")
+	is.NotContains(conf, "Address")
+	is.NotContains(conf, "DNS")
+	is.NotContains(conf, "PostUp")
+
+	_, er = parseWireGuard([]byte("[Interface]\nPrivateKey = x\n"))
+	is.Error(er)
+}
+
+func TestWireGuardSetconf(t *testing.T) {
+	is := assert.New(t)
+	dir, er := ioutil.TempDir("", "transmon")
+	if er != nil {
+		t.Fatal(er)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "wg0.conf")
+	if er := ioutil.WriteFile(file, []byte(wgConf), 0600); er != nil {
+		t.Fatal(er)
+	}
+
+	var ran []string
+	w := NewWireGuard(file, "wg0", config.WGSetconf)
+	w.run = func(args ...string) error {
+		ran = append(ran, strings.Join(args, " "))
+		return nil
+	}
+	w.exists = func(string) bool { return false }
+
+	is.NoError(w.Start(context.Background()))
+	if is.Len(ran, 9) {
+		is.Equal("ip link add dev wg0 type wireguard", ran[0])
+		is.True(strings.HasPrefix(ran[1], "wg setconf wg0 "))
+		is.Equal("ip address add 10.2.0.2/32 dev wg0", ran[2])
+		is.Equal("ip -4 route add default dev wg0 table 51820", ran[5])
+		is.Equal("ip -6 route add default dev wg0 table 51820", ran[6], "ipv6 from the tunnel address must not leak")
+		is.Equal("ip -4 rule add from 10.2.0.2 table 51820", ran[7])
+		is.Equal("ip -6 rule add from fd00::2 table 51820", ran[8])
+	}
+
+	ran = nil
+	is.NoError(w.Stop())
+	is.Equal("ip link del dev wg0", ran[len(ran)-1])
+
+	ran = nil
+	w.Mode = config.WGQuick
+	is.NoError(w.Start(context.Background()))
+	is.Equal([]string{"wg-quick up " + file}, ran)
+
+	ran = nil
+	w.exists = func(string) bool { return true }
+	is.NoError(w.Start(context.Background()))
+	is.Equal([]string{"wg-quick down " + file, "wg-quick up " + file}, ran, "an interface left by a crash is taken down first")
+}